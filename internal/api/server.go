@@ -6,6 +6,7 @@ import (
 
 	"exam-helper/internal/config"
 	"exam-helper/internal/handlers"
+	"exam-helper/internal/repository"
 	"exam-helper/internal/services"
 
 	"github.com/gin-contrib/cors"
@@ -30,8 +31,8 @@ func NewServer(cfg *config.Config) *Server {
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = cfg.AllowedOrigins
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", handlers.OwnerTokenHeader}
 	corsConfig.AllowCredentials = true
 	router.Use(cors.New(corsConfig))
 
@@ -40,15 +41,39 @@ func NewServer(cfg *config.Config) *Server {
 		panic("Failed to create upload directory: " + err.Error())
 	}
 
+	// Initialize storage
+	examRepo, err := repository.New(cfg.StorageDriver, cfg.DataDir)
+	if err != nil {
+		panic("Failed to initialize storage: " + err.Error())
+	}
+
+	latePolicy, err := services.ParseLatePolicy(cfg.LatePolicy)
+	if err != nil {
+		panic("Invalid LATE_SUBMISSION_POLICY: " + err.Error())
+	}
+
 	// Initialize services
 	pdfService := services.NewPDFService()
-	examService := services.NewExamService(pdfService)
+	examService := services.NewExamService(examRepo, pdfService, services.SystemClock, services.SubmissionRules{
+		GracePeriod: cfg.GracePeriod,
+		LatePolicy:  latePolicy,
+	})
+
+	// Re-arm timers for exams that were active before a restart
+	if err := examService.RestoreTimers(); err != nil {
+		panic("Failed to restore exam timers: " + err.Error())
+	}
+
+	// Purge archived exams and orphaned uploads in the background
+	janitor := services.NewJanitor(examService, cfg.UploadDir, services.SystemClock, cfg.Retention, cfg.JanitorPeriod)
+	janitor.Start()
 
 	// Initialize handlers
-	examHandler := handlers.NewExamHandler(examService, pdfService, cfg.UploadDir)
+	examHandler := handlers.NewExamHandler(examService, pdfService, cfg.UploadDir, cfg.MaxFileSize)
+	answerKeyHandler := handlers.NewAnswerKeyHandler(pdfService, cfg.MaxFileSize)
 
 	// Setup routes
-	setupRoutes(router, examHandler, cfg)
+	setupRoutes(router, examHandler, answerKeyHandler, cfg)
 
 	return &Server{
 		router: router,
@@ -62,7 +87,7 @@ func (s *Server) Run(addr string) error {
 }
 
 // setupRoutes configures all API routes
-func setupRoutes(router *gin.Engine, examHandler *handlers.ExamHandler, cfg *config.Config) {
+func setupRoutes(router *gin.Engine, examHandler *handlers.ExamHandler, answerKeyHandler *handlers.AnswerKeyHandler, cfg *config.Config) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -77,18 +102,33 @@ func setupRoutes(router *gin.Engine, examHandler *handlers.ExamHandler, cfg *con
 		// Exam endpoints
 		exams := api.Group("/exams")
 		{
+			exams.GET("", examHandler.ListExams)
 			exams.POST("", examHandler.CreateExam)
 			exams.GET("/:id", examHandler.GetExam)
+			exams.DELETE("/:id", examHandler.DeleteExam)
+			exams.POST("/:id/archive", examHandler.ArchiveExam)
 			exams.POST("/:id/start", examHandler.StartExam)
+			exams.PATCH("/:id/answers", examHandler.SaveAnswers)
 			exams.POST("/:id/submit", examHandler.SubmitAnswers)
 			exams.GET("/:id/status", examHandler.GetExamStatus)
+			exams.GET("/:id/result", examHandler.GetResult)
+			exams.GET("/:id/metadata", examHandler.GetExamMetadata)
 			exams.GET("/:id/answer-key-preview", examHandler.GetAnswerKeyPreview)
+			exams.GET("/:id/answer-key", examHandler.DownloadAnswerKey)
+			exams.PUT("/:id/answer-key", examHandler.ReviseAnswerKey)
+		}
+
+		// Answer key endpoints
+		answerKeys := api.Group("/answer-keys")
+		{
+			answerKeys.POST("/validate", answerKeyHandler.ValidateAnswerKey)
 		}
 	}
 
-	// Serve uploaded files (for development)
+	// Serve uploaded exam files (for development); answer keys stay behind the access checks
 	if cfg.Debug {
-		router.Static("/uploads", cfg.UploadDir)
+		router.GET("/uploads/*filepath", examHandler.ServeFile)
+		router.HEAD("/uploads/*filepath", examHandler.ServeFile)
 	}
 
 	// Serve frontend static files in production (only if build directory exists)
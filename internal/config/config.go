@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds all application configuration
@@ -12,6 +13,12 @@ type Config struct {
 	MaxFileSize    int64
 	AllowedOrigins []string
 	Debug          bool
+	StorageDriver  string
+	DataDir        string
+	GracePeriod    time.Duration
+	LatePolicy     string
+	Retention      time.Duration
+	JanitorPeriod  time.Duration
 }
 
 // Load creates a new configuration instance with default values and environment overrides
@@ -22,6 +29,12 @@ func Load() *Config {
 		MaxFileSize:    getEnvInt64("MAX_FILE_SIZE", 10*1024*1024), // 10MB default
 		AllowedOrigins: []string{getEnv("FRONTEND_URL", "http://localhost:3000")},
 		Debug:          getEnvBool("DEBUG", true),
+		StorageDriver:  getEnv("STORAGE_DRIVER", "memory"), // "memory" or "file"
+		DataDir:        getEnv("DATA_DIR", "./data"),
+		GracePeriod:    getEnvDuration("SUBMIT_GRACE_PERIOD", 5*time.Second),
+		LatePolicy:     getEnv("LATE_SUBMISSION_POLICY", "reject"), // "reject", "flag" or "autosaved"
+		Retention:      getEnvDuration("RETENTION_PERIOD", 30*24*time.Hour),
+		JanitorPeriod:  getEnvDuration("JANITOR_INTERVAL", time.Hour), // Zero disables the janitor
 	}
 
 	return cfg
@@ -54,3 +67,13 @@ func getEnvBool(key string, defaultValue bool) bool {
 
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationValue, err := time.ParseDuration(value); err == nil {
+			return durationValue
+		}
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"exam-helper/internal/models"
+	"exam-helper/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnswerKeyHandler handles answer key requests that are not tied to an exam
+type AnswerKeyHandler struct {
+	pdfService  *services.PDFService
+	maxFileSize int64
+}
+
+// NewAnswerKeyHandler creates a new answer key handler instance
+// maxFileSize limits the uploaded key, in bytes
+func NewAnswerKeyHandler(pdfService *services.PDFService, maxFileSize int64) *AnswerKeyHandler {
+	return &AnswerKeyHandler{
+		pdfService:  pdfService,
+		maxFileSize: maxFileSize,
+	}
+}
+
+// ValidateAnswerKey parses an uploaded answer key and reports problems without storing anything
+// The key is read into memory and never written to the upload directory
+func (h *AnswerKeyHandler) ValidateAnswerKey(c *gin.Context) {
+	if err := parseUploadForm(c, h.maxFileSize, 1); err != nil {
+		respondFormError(c, err)
+		return
+	}
+
+	strict, err := parseStrictFlag(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	optionSet, err := models.ParseOptionSet(c.PostForm("options"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("answer_key")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer key file is required"})
+		return
+	}
+	defer file.Close()
+
+	if !checkFileSize(c, "answer_key", header, h.maxFileSize) {
+		return
+	}
+
+	if !isValidFileType(header.Filename, []string{".txt", ".pdf"}) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer key file must be a TXT or PDF file"})
+		return
+	}
+
+	// Answer keys are parsed in memory; the read is capped at the size limit
+	data, err := io.ReadAll(io.LimitReader(file, h.maxFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to read answer key file: %v", err)})
+		return
+	}
+
+	if err := h.pdfService.CheckAnswerKeyFile(header.Filename, data); err != nil {
+		respondUploadError(c, "answer_key", err)
+		return
+	}
+
+	answerKey, diagnostics, err := h.pdfService.ParseAnswerKeyData(header.Filename, data, optionSet)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"valid":       false,
+			"strict":      strict,
+			"error":       err.Error(),
+			"diagnostics": diagnostics,
+		})
+		return
+	}
+
+	// Strict mode only looks at parse problems; analysis findings are informational
+	err = h.pdfService.ValidateAnswerKeyFormat(answerKey)
+	if err == nil && strict {
+		err = h.pdfService.EnforceStrict(diagnostics)
+	}
+
+	analysis := h.pdfService.AnalyzeAnswerKey(answerKey)
+
+	response := gin.H{
+		"valid":               err == nil,
+		"strict":              strict,
+		"answer_key":          answerKey,
+		"question_count":      analysis.QuestionCount,
+		"option_distribution": analysis.OptionDistribution,
+		"diagnostics":         append(diagnostics, analysis.Diagnostics...),
+	}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
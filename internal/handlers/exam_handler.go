@@ -1,40 +1,49 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"exam-helper/internal/models"
+	"exam-helper/internal/repository"
 	"exam-helper/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// OwnerTokenHeader carries the owner token returned when an exam is created
+const OwnerTokenHeader = "X-Owner-Token"
+
 // ExamHandler handles exam-related HTTP requests
 type ExamHandler struct {
 	examService *services.ExamService
 	pdfService  *services.PDFService
 	uploadDir   string
+	maxFileSize int64
 }
 
 // NewExamHandler creates a new exam handler instance
-func NewExamHandler(examService *services.ExamService, pdfService *services.PDFService, uploadDir string) *ExamHandler {
+// maxFileSize limits each uploaded file, in bytes
+func NewExamHandler(examService *services.ExamService, pdfService *services.PDFService, uploadDir string, maxFileSize int64) *ExamHandler {
 	return &ExamHandler{
 		examService: examService,
 		pdfService:  pdfService,
 		uploadDir:   uploadDir,
+		maxFileSize: maxFileSize,
 	}
 }
 
 // CreateExam handles the creation of a new exam
 func (h *ExamHandler) CreateExam(c *gin.Context) {
-	// Parse multipart form
-	err := c.Request.ParseMultipartForm(10 << 20) // 10 MB max
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+	// Parse multipart form; uploaded files are spooled to disk rather than held in memory
+	if err := parseUploadForm(c, h.maxFileSize, 2); err != nil {
+		respondFormError(c, err)
 		return
 	}
 
@@ -42,6 +51,31 @@ func (h *ExamHandler) CreateExam(c *gin.Context) {
 	mode := c.PostForm("mode")
 	durationStr := c.PostForm("duration")
 
+	strict, err := parseStrictFlag(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scoring, err := parseScoringConfig(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Option set shared by the answer key, validation, grading and the answer form
+	// Certo/Errado scoring implies the C/E option set when none is given
+	options := c.PostForm("options")
+	if options == "" && scoring.Scheme == models.ScoringCespe {
+		options = models.OptionSetCE
+	}
+
+	optionSet, err := models.ParseOptionSet(options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Validate mode
 	if mode != string(models.ModeTimer) && mode != string(models.ModeStopwatch) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mode. Must be 'timer' or 'stopwatch'"})
@@ -81,6 +115,10 @@ func (h *ExamHandler) CreateExam(c *gin.Context) {
 	}
 	defer answerKeyFile.Close()
 
+	if !checkFileSize(c, "exam_pdf", examHeader, h.maxFileSize) || !checkFileSize(c, "answer_key", answerKeyHeader, h.maxFileSize) {
+		return
+	}
+
 	// Validate file types
 	if !isValidFileType(examHeader.Filename, []string{".pdf"}) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam file must be a PDF"})
@@ -92,40 +130,129 @@ func (h *ExamHandler) CreateExam(c *gin.Context) {
 		return
 	}
 
-	// Save files
-	examPath, err := saveUploadedFile(c, examFile, examHeader, h.uploadDir, "exam")
+	// Stage files; they only reach the upload directory once the exam is created
+	uploads, err := newUploadBatch(h.uploadDir)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save exam file: %v", err)})
 		return
 	}
+	defer uploads.Cleanup()
 
-	answerKeyPath, err := saveUploadedFile(c, answerKeyFile, answerKeyHeader, h.uploadDir, "answer_key")
+	examUpload, err := uploads.Stage(examFile, examHeader, examFilePrefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save exam file: %v", err)})
+		return
+	}
+
+	answerKeyUpload, err := uploads.Stage(answerKeyFile, answerKeyHeader, answerKeyFilePrefix)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save answer key file: %v", err)})
 		return
 	}
 
+	// File names are only a hint; check what the files actually contain
+	if err := h.pdfService.CheckExamPDF(examUpload.Path); err != nil {
+		respondUploadError(c, "exam_pdf", err)
+		return
+	}
+
+	if err := checkUpload(answerKeyUpload, func(data []byte) error {
+		return h.pdfService.CheckAnswerKeyFile(answerKeyHeader.Filename, data)
+	}); err != nil {
+		respondUploadError(c, "answer_key", err)
+		return
+	}
+
+	// Parse the answer key once; grading and previews use the stored result
+	answerKey, diagnostics, err := h.pdfService.ParseAnswerKey(answerKeyUpload.Path, optionSet)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       fmt.Sprintf("Invalid answer key format: %v", err),
+			"diagnostics": diagnostics,
+		})
+		return
+	}
+
 	// Validate answer key format
-	if err := h.pdfService.ValidateAnswerKeyFormat(answerKeyPath); err != nil {
+	if err := h.pdfService.ValidateAnswerKeyFormat(answerKey); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid answer key format: %v", err)})
 		return
 	}
 
+	if strict {
+		if err := h.pdfService.EnforceStrict(diagnostics); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       fmt.Sprintf("Invalid answer key format: %v", err),
+				"diagnostics": diagnostics,
+			})
+			return
+		}
+	}
+
 	// Create exam
 	req := models.CreateExamRequest{
 		Mode:     models.ExamMode(mode),
 		Duration: duration,
+		Scoring:  scoring,
+		Owner:    strings.TrimSpace(c.PostForm("owner")),
+	}
+
+	// Files are committed before the exam is stored, so a stored exam never points at missing files
+	// If creating the exam fails, Cleanup removes the committed files again
+	if err := uploads.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save uploaded files: %v", err)})
+		return
 	}
 
-	exam, err := h.examService.CreateExam(req, examPath, answerKeyPath)
+	exam, ownerToken, err := h.examService.CreateExam(req, examUpload.FinalPath, answerKeyUpload.FinalPath, answerKey)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidExam) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create exam: %v", err)})
 		return
 	}
+	uploads.Keep()
 
+	// The owner token is only ever returned here; it is needed to see or revise the key before the exam ends
 	c.JSON(http.StatusCreated, gin.H{
-		"exam":    exam,
-		"message": "Exam created successfully",
+		"exam":        exam.Redacted(),
+		"owner_token": ownerToken,
+		"diagnostics": diagnostics,
+		"message":     "Exam created successfully",
+	})
+}
+
+// ListExams returns one page of the caller's exams, filtered by the query string
+// Only exams whose owner token was sent in X-Owner-Token are listed
+// Filters: mode, status, owner, created_after and created_before (RFC 3339)
+// Sorting: sort=created_at|updated_at and order=asc|desc; paging: limit and cursor
+func (h *ExamHandler) ListExams(c *gin.Context) {
+	query, err := parseExamQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.examService.ListExams(query, ownerTokens(c))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to list exams: %v", err)})
+		return
+	}
+
+	exams := make([]*models.Exam, 0, len(page.Exams))
+	for _, exam := range page.Exams {
+		exams = append(exams, exam.Redacted())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exams":       exams,
+		"next_cursor": page.NextCursor,
 	})
 }
 
@@ -144,7 +271,7 @@ func (h *ExamHandler) StartExam(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"exam":    exam,
+		"exam":    exam.Redacted(),
 		"message": "Exam started successfully",
 	})
 }
@@ -165,6 +292,10 @@ func (h *ExamHandler) SubmitAnswers(c *gin.Context) {
 
 	result, err := h.examService.SubmitAnswers(examID, req.Answers)
 	if err != nil {
+		if errors.Is(err, services.ErrDeadlinePassed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -175,6 +306,40 @@ func (h *ExamHandler) SubmitAnswers(c *gin.Context) {
 	})
 }
 
+// SaveAnswers handles partial answer autosaves
+func (h *ExamHandler) SaveAnswers(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	var req models.SaveAnswersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	exam, err := h.examService.SaveAnswers(examID, req.Answers, *req.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrStaleAnswers), errors.Is(err, services.ErrDeadlinePassed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"answers": exam.Answers,
+		"version": exam.AnswersVersion,
+		"message": "Answers saved successfully",
+	})
+}
+
 // GetExam retrieves exam information
 func (h *ExamHandler) GetExam(c *gin.Context) {
 	examID := c.Param("id")
@@ -189,7 +354,159 @@ func (h *ExamHandler) GetExam(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"exam": exam})
+	c.JSON(http.StatusOK, gin.H{"exam": exam.Redacted()})
+}
+
+// GetResult retrieves the stored result of a completed or expired exam
+func (h *ExamHandler) GetResult(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	result, err := h.examService.GetResult(examID)
+	if err != nil {
+		if errors.Is(err, services.ErrResultNotAvailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := h.examService.GetResultHistory(examID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":  result,
+		"history": history,
+	})
+}
+
+// ReviseAnswerKey uploads a revised answer key for an existing exam and re-grades it if it has ended
+func (h *ExamHandler) ReviseAnswerKey(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	if err := parseUploadForm(c, h.maxFileSize, 1); err != nil {
+		respondFormError(c, err)
+		return
+	}
+
+	strict, err := parseStrictFlag(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exam, err := h.examService.GetExam(examID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Checked before saving anything; the service checks again when applying the revision
+	if !exam.IsOwner(ownerToken(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrNotOwner.Error()})
+		return
+	}
+
+	answerKeyFile, answerKeyHeader, err := c.Request.FormFile("answer_key")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer key file is required"})
+		return
+	}
+	defer answerKeyFile.Close()
+
+	if !checkFileSize(c, "answer_key", answerKeyHeader, h.maxFileSize) {
+		return
+	}
+
+	if !isValidFileType(answerKeyHeader.Filename, []string{".txt", ".pdf"}) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Answer key file must be a TXT or PDF file"})
+		return
+	}
+
+	uploads, err := newUploadBatch(h.uploadDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save answer key file: %v", err)})
+		return
+	}
+	defer uploads.Cleanup()
+
+	answerKeyUpload, err := uploads.Stage(answerKeyFile, answerKeyHeader, answerKeyFilePrefix)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save answer key file: %v", err)})
+		return
+	}
+
+	if err := checkUpload(answerKeyUpload, func(data []byte) error {
+		return h.pdfService.CheckAnswerKeyFile(answerKeyHeader.Filename, data)
+	}); err != nil {
+		respondUploadError(c, "answer_key", err)
+		return
+	}
+
+	// The revision is parsed with the exam's option set so stored answers stay comparable
+	answerKey, diagnostics, err := h.pdfService.ParseAnswerKey(answerKeyUpload.Path, exam.Options())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":       fmt.Sprintf("Invalid answer key format: %v", err),
+			"diagnostics": diagnostics,
+		})
+		return
+	}
+
+	if err := h.pdfService.ValidateAnswerKeyFormat(answerKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid answer key format: %v", err)})
+		return
+	}
+
+	if strict {
+		if err := h.pdfService.EnforceStrict(diagnostics); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       fmt.Sprintf("Invalid answer key format: %v", err),
+				"diagnostics": diagnostics,
+			})
+			return
+		}
+	}
+
+	if err := uploads.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save answer key file: %v", err)})
+		return
+	}
+
+	exam, err = h.examService.ReviseAnswerKey(examID, ownerToken(c), answerKeyUpload.FinalPath, answerKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidExam):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to revise answer key: %v", err)})
+		}
+		return
+	}
+	uploads.Keep()
+
+	c.JSON(http.StatusOK, gin.H{
+		"exam":         exam.Redacted(),
+		"key_revision": exam.KeyRevision,
+		"result":       exam.Result,
+		"diagnostics":  diagnostics,
+		"message":      "Answer key revised successfully",
+	})
 }
 
 // GetExamStatus retrieves exam status and timing information
@@ -209,7 +526,81 @@ func (h *ExamHandler) GetExamStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// ArchiveExam moves an exam to the archived state
+func (h *ExamHandler) ArchiveExam(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	exam, err := h.examService.ArchiveExam(examID, ownerToken(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrExamInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to archive exam: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exam":    exam.Redacted(),
+		"message": "Exam archived successfully",
+	})
+}
+
+// DeleteExam permanently removes an exam and its uploaded files
+func (h *ExamHandler) DeleteExam(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	if err := h.examService.DeleteExam(examID, ownerToken(c)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to delete exam: %v", err)})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exam deleted successfully"})
+}
+
+// GetExamMetadata returns the question numbers, options and gaps of an exam without any answers
+func (h *ExamHandler) GetExamMetadata(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	metadata, err := h.examService.GetExamMetadata(examID)
+	if err != nil {
+		if errors.Is(err, repository.ErrExamNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get exam metadata: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"metadata": metadata})
+}
+
 // GetAnswerKeyPreview returns a preview of the answer key for validation
+// Only the owner may see it before the exam ends
 func (h *ExamHandler) GetAnswerKeyPreview(c *gin.Context) {
 	examID := c.Param("id")
 	if examID == "" {
@@ -217,19 +608,212 @@ func (h *ExamHandler) GetAnswerKeyPreview(c *gin.Context) {
 		return
 	}
 
-	exam, err := h.examService.GetExam(examID)
+	answerKey, err := h.examService.RevealAnswerKey(examID, ownerToken(c))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAnswerKeyHidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get answer key preview: %v", err)})
+		}
 		return
 	}
 
-	preview, err := h.pdfService.GetAnswerKeyPreview(exam.AnswerKeyPath)
+	c.JSON(http.StatusOK, gin.H{
+		"preview": h.pdfService.GetAnswerKeyPreview(answerKey),
+		"options": answerKey.OptionSet.Options,
+	})
+}
+
+// DownloadAnswerKey serves the uploaded answer key file with the same access rules as the preview
+func (h *ExamHandler) DownloadAnswerKey(c *gin.Context) {
+	examID := c.Param("id")
+	if examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exam ID is required"})
+		return
+	}
+
+	path, err := h.examService.AnswerKeyFile(examID, ownerToken(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get answer key preview: %v", err)})
+		switch {
+		case errors.Is(err, repository.ErrExamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAnswerKeyHidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get answer key file: %v", err)})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"preview": preview})
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer key file is no longer available"})
+		return
+	}
+
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// ownerToken returns the owner token sent with the request, if any
+func ownerToken(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(OwnerTokenHeader))
+}
+
+// ownerTokens returns every owner token sent with the request
+// Tokens may be repeated headers or comma-separated, so a client can list all of its exams at once
+func ownerTokens(c *gin.Context) []string {
+	var tokens []string
+	for _, value := range c.Request.Header.Values(OwnerTokenHeader) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	return tokens
+}
+
+// parseExamQuery reads the listing filters, sorting and paging parameters
+func parseExamQuery(c *gin.Context) (repository.ExamQuery, error) {
+	query := repository.ExamQuery{
+		Mode:   models.ExamMode(c.Query("mode")),
+		Status: models.ExamStatus(c.Query("status")),
+		Owner:  c.Query("owner"),
+		SortBy: c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+
+	if query.Mode != "" && query.Mode != models.ModeTimer && query.Mode != models.ModeStopwatch {
+		return query, errors.New("Invalid mode. Must be 'timer' or 'stopwatch'")
+	}
+
+	switch query.Status {
+	case "", models.StatusPending, models.StatusActive, models.StatusCompleted, models.StatusExpired, models.StatusArchived:
+	default:
+		return query, fmt.Errorf("invalid status %q", query.Status)
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return query, errors.New("order must be 'asc' or 'desc'")
+	}
+
+	for name, target := range map[string]**time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		if value := c.Query(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*target = &t
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return query, errors.New("limit must be a positive integer")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// parseStrictFlag reads the optional "strict" form field
+func parseStrictFlag(c *gin.Context) (bool, error) {
+	value := c.PostForm("strict")
+	if value == "" {
+		return false, nil
+	}
+
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("strict must be a boolean")
+	}
+
+	return strict, nil
+}
+
+// parseScoringConfig reads the scoring scheme and its parameters from the form
+// Weights use ranges of question numbers, e.g. "1-10:1,11-20:2"
+func parseScoringConfig(c *gin.Context) (models.ScoringConfig, error) {
+	config := models.ScoringConfig{
+		Scheme: models.ScoringScheme(c.PostForm("scoring")),
+	}
+
+	if value := c.PostForm("penalty"); value != "" {
+		penalty, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return config, errors.New("penalty must be a number")
+		}
+		// Accept both "0.25" and "-0.25"
+		if penalty < 0 {
+			penalty = -penalty
+		}
+		config.Penalty = penalty
+	}
+
+	if value := c.PostForm("wrongs_per_right"); value != "" {
+		wrongs, err := strconv.Atoi(value)
+		if err != nil {
+			return config, errors.New("wrongs_per_right must be an integer")
+		}
+		config.WrongsPerRight = wrongs
+	}
+
+	if value := c.PostForm("weights"); value != "" {
+		config.Weights = make(map[string]float64)
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+			if len(parts) != 2 {
+				return config, fmt.Errorf("invalid weight entry %q. Expected <questions>:<weight>", entry)
+			}
+
+			weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			if err != nil {
+				return config, fmt.Errorf("invalid weight in %q", entry)
+			}
+
+			first, last, err := parseQuestionRange(parts[0])
+			if err != nil {
+				return config, err
+			}
+			for n := first; n <= last; n++ {
+				config.Weights[strconv.Itoa(n)] = weight
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// parseQuestionRange parses "7" or "1-10" into an inclusive range of question numbers
+func parseQuestionRange(value string) (int, int, error) {
+	bounds := strings.SplitN(strings.TrimSpace(value), "-", 2)
+
+	first, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil || first <= 0 {
+		return 0, 0, fmt.Errorf("invalid question number %q", value)
+	}
+
+	last := first
+	if len(bounds) == 2 {
+		last, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+		if err != nil || last < first || last-first > 10000 {
+			return 0, 0, fmt.Errorf("invalid question range %q", value)
+		}
+	}
+
+	return first, last, nil
 }
 
 // isValidFileType checks if the file has a valid extension
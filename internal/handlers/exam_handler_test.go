@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exam-helper/internal/repository"
+	"exam-helper/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testMaxFileSize = 1 << 20
+
+// testPDF is the smallest file the exam upload check accepts
+const testPDF = "%PDF-1.4\n1 0 obj\n<</Type /Catalog>>\nendobj\ntrailer\n<</Root 1 0 R>>\n%%EOF\n"
+
+const testAnswerKey = "1. A\n2. B\n3. C\n"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type testServer struct {
+	router    *gin.Engine
+	service   *services.ExamService
+	clock     *services.FakeClock
+	uploadDir string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	clock := services.NewFakeClock(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	pdfService := services.NewPDFService()
+	examService := services.NewExamService(repository.NewMemoryRepository(), pdfService, clock, services.SubmissionRules{})
+	t.Cleanup(examService.Close)
+
+	uploadDir := t.TempDir()
+	handler := NewExamHandler(examService, pdfService, uploadDir, testMaxFileSize)
+
+	router := gin.New()
+	exams := router.Group("/api/v1/exams")
+	exams.GET("", handler.ListExams)
+	exams.POST("", handler.CreateExam)
+	exams.POST("/:id/start", handler.StartExam)
+	exams.POST("/:id/submit", handler.SubmitAnswers)
+	exams.GET("/:id/answer-key-preview", handler.GetAnswerKeyPreview)
+	exams.GET("/:id/answer-key", handler.DownloadAnswerKey)
+	exams.PUT("/:id/answer-key", handler.ReviseAnswerKey)
+	router.GET("/uploads/*filepath", handler.ServeFile)
+
+	return &testServer{router: router, service: examService, clock: clock, uploadDir: uploadDir}
+}
+
+func (s *testServer) do(t *testing.T, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *testServer) request(t *testing.T, method, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set(OwnerTokenHeader, token)
+	}
+	return s.do(t, req)
+}
+
+// uploadForm builds a multipart exam creation request
+func uploadForm(t *testing.T, fields map[string]string, files map[string][2]string) *http.Request {
+	t.Helper()
+	return multipartRequest(t, http.MethodPost, "/api/v1/exams", fields, files)
+}
+
+// multipartRequest builds a multipart request; files maps a form field to its file name and content
+func multipartRequest(t *testing.T, method, path string, fields map[string]string, files map[string][2]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for field, file := range files {
+		part, err := form.CreateFormFile(field, file[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(file[1]))
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+type createdExam struct {
+	Exam struct {
+		ID          string `json:"id"`
+		ExamPDFPath string `json:"exam_pdf_path"`
+	} `json:"exam"`
+	OwnerToken string `json:"owner_token"`
+}
+
+// createExam creates a stopwatch exam with the test PDF and answer key
+func (s *testServer) createExam(t *testing.T) createdExam {
+	t.Helper()
+	return s.createExamWith(t, map[string]string{"mode": "stopwatch"})
+}
+
+// createExamWith creates an exam from the given form fields with the test PDF and answer key
+func (s *testServer) createExamWith(t *testing.T, fields map[string]string) createdExam {
+	t.Helper()
+	w := s.do(t, uploadForm(t, fields, map[string][2]string{
+		"exam_pdf":   {"prova.pdf", testPDF},
+		"answer_key": {"gabarito.txt", testAnswerKey},
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create exam: %d %s", w.Code, w.Body)
+	}
+
+	var created createdExam
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// submit posts answers as JSON
+func (s *testServer) submit(t *testing.T, examID, answers string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/exams/"+examID+"/submit", bytes.NewBufferString(`{"answers":`+answers+`}`))
+	req.Header.Set("Content-Type", "application/json")
+	return s.do(t, req)
+}
+
+func TestLateSubmitConflict(t *testing.T) {
+	s := newTestServer(t)
+	exam := s.createExamWith(t, map[string]string{"mode": "timer", "duration": "30"})
+	if w := s.request(t, http.MethodPost, "/api/v1/exams/"+exam.Exam.ID+"/start", ""); w.Code != http.StatusOK {
+		t.Fatalf("start: %d %s", w.Code, w.Body)
+	}
+
+	// Once the timer has expired the exam, a late submission is refused like one that beat the timer
+	s.clock.Advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		if w := s.submit(t, exam.Exam.ID, `{"1":"A"}`); w.Code != http.StatusConflict {
+			t.Fatalf("late submit %d: %d %s, want 409", i+1, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAnswerKeyAccess(t *testing.T) {
+	s := newTestServer(t)
+	exam := s.createExam(t)
+	base := "/api/v1/exams/" + exam.Exam.ID
+
+	if w := s.request(t, http.MethodPost, base+"/start", ""); w.Code != http.StatusOK {
+		t.Fatalf("start: %d %s", w.Code, w.Body)
+	}
+
+	for _, path := range []string{base + "/answer-key-preview", base + "/answer-key"} {
+		if w := s.request(t, http.MethodGet, path, ""); w.Code != http.StatusForbidden {
+			t.Errorf("GET %s without a token during the exam: %d, want 403", path, w.Code)
+		}
+		if w := s.request(t, http.MethodGet, path, "wrong-token"); w.Code != http.StatusForbidden {
+			t.Errorf("GET %s with a wrong token during the exam: %d, want 403", path, w.Code)
+		}
+		if w := s.request(t, http.MethodGet, path, exam.OwnerToken); w.Code != http.StatusOK {
+			t.Errorf("GET %s with the owner token: %d, want 200", path, w.Code)
+		}
+	}
+
+	if w := s.submit(t, exam.Exam.ID, `{"1":"A"}`); w.Code != http.StatusOK {
+		t.Fatalf("submit: %d %s", w.Code, w.Body)
+	}
+
+	for _, path := range []string{base + "/answer-key-preview", base + "/answer-key"} {
+		if w := s.request(t, http.MethodGet, path, ""); w.Code != http.StatusOK {
+			t.Errorf("GET %s without a token after the exam: %d, want 200", path, w.Code)
+		}
+	}
+}
+
+func TestReviseAnswerKeyHandler(t *testing.T) {
+	s := newTestServer(t)
+	exam := s.createExam(t)
+	path := "/api/v1/exams/" + exam.Exam.ID + "/answer-key"
+
+	if w := s.request(t, http.MethodPost, "/api/v1/exams/"+exam.Exam.ID+"/start", ""); w.Code != http.StatusOK {
+		t.Fatalf("start: %d %s", w.Code, w.Body)
+	}
+	if w := s.submit(t, exam.Exam.ID, `{"1":"A","2":"C","3":"C"}`); w.Code != http.StatusOK {
+		t.Fatalf("submit: %d %s", w.Code, w.Body)
+	}
+
+	revise := func(token string) *httptest.ResponseRecorder {
+		req := multipartRequest(t, http.MethodPut, path, nil, map[string][2]string{
+			"answer_key": {"gabarito.txt", "1. A\n2. C\n3. C\n"},
+		})
+		if token != "" {
+			req.Header.Set(OwnerTokenHeader, token)
+		}
+		return s.do(t, req)
+	}
+
+	if w := revise("wrong-token"); w.Code != http.StatusForbidden {
+		t.Fatalf("revision with a wrong token: %d %s, want 403", w.Code, w.Body)
+	}
+
+	w := revise(exam.OwnerToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("revision: %d %s", w.Code, w.Body)
+	}
+
+	var revised struct {
+		KeyRevision int `json:"key_revision"`
+		Result      struct {
+			CorrectAnswers int `json:"correct_answers"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &revised); err != nil {
+		t.Fatal(err)
+	}
+	if revised.Result.CorrectAnswers != 3 {
+		t.Fatalf("re-graded result has %d correct, want 3", revised.Result.CorrectAnswers)
+	}
+
+	stored, err := s.service.GetExam(exam.Exam.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.KeyRevision != revised.KeyRevision || len(stored.ResultHistory) != 1 {
+		t.Fatalf("stored exam has key revision %d and %d old results, want %d and 1",
+			stored.KeyRevision, len(stored.ResultHistory), revised.KeyRevision)
+	}
+}
+
+func TestServeFileOnlyServesExamPDFs(t *testing.T) {
+	s := newTestServer(t)
+	exam := s.createExam(t)
+
+	w := s.request(t, http.MethodGet, "/uploads/"+filepath.Base(exam.Exam.ExamPDFPath), "")
+	if w.Code != http.StatusOK || w.Body.String() != testPDF {
+		t.Fatalf("exam PDF: %d, want 200 with the uploaded file", w.Code)
+	}
+
+	// Answer keys are refused because they are not an exam PDF, whatever their file name
+	stored, err := s.service.GetExam(exam.Exam.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		"/uploads/" + filepath.Base(stored.AnswerKeyPath),
+		"/uploads/missing.pdf",
+		"/uploads/../../etc/passwd",
+		"/uploads/" + services.StagingDirName,
+		"/uploads/",
+	} {
+		if w := s.request(t, http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: %d, want 404", path, w.Code)
+		}
+	}
+}
@@ -1,49 +1,225 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
+	"exam-helper/internal/services"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// saveUploadedFile saves an uploaded file to the specified directory
-func saveUploadedFile(c *gin.Context, file multipart.File, header *multipart.FileHeader, uploadDir, prefix string) (string, error) {
-	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create upload directory: %w", err)
+// Prefixes of uploaded file names, so the upload directory is easy to browse
+const (
+	examFilePrefix      = "exam"
+	answerKeyFilePrefix = "answer_key"
+)
+
+// Multipart parsing limits; file parts beyond multipartMemory are written to temporary files instead of memory
+const (
+	multipartMemory = 1 << 20
+	formOverhead    = 1 << 20 // Allowance for form fields and multipart headers on top of the files
+)
+
+// parseUploadForm caps the request body and parses the multipart form
+// The cap is files times maxFileSize plus formOverhead, so no request can exceed what its files may add up to
+func parseUploadForm(c *gin.Context, maxFileSize int64, files int) error {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(files)*maxFileSize+formOverhead)
+
+	return c.Request.ParseMultipartForm(multipartMemory)
+}
+
+// respondFormError reports a multipart form that could not be parsed, with 413 when it was too large
+func respondFormError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Request is larger than the %d bytes allowed", maxBytesErr.Limit),
+			"code":  services.UploadTooLarge,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
+}
+
+// checkFileSize rejects a single uploaded file larger than maxFileSize with 413
+func checkFileSize(c *gin.Context, field string, header *multipart.FileHeader, maxFileSize int64) bool {
+	if header.Size <= maxFileSize {
+		return true
+	}
+
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf("Invalid %s: file is larger than the %d bytes allowed", field, maxFileSize),
+		"code":  services.UploadTooLarge,
+		"field": field,
+	})
+
+	return false
+}
+
+// renameFile moves a staged file into place; tests replace it to simulate a failing disk
+var renameFile = os.Rename
+
+// uploadBatch stages uploaded files outside the upload directory until the request that carries them succeeds
+// Files are written to a private staging directory, moved into place by Commit, and removed by Cleanup
+// unless Keep was called, so a failed request leaves nothing behind
+type uploadBatch struct {
+	uploadDir  string
+	stagingDir string
+	staged     []*stagedUpload
+	committed  []string
+	kept       bool
+}
+
+// stagedUpload is one file of an upload batch
+type stagedUpload struct {
+	Path      string // Where the file can be read before the batch is committed
+	FinalPath string // Where the file lives once the batch is committed
+}
+
+// newUploadBatch creates a batch whose staging directory sits inside uploadDir, so Commit is a rename
+func newUploadBatch(uploadDir string) (*uploadBatch, error) {
+	stagingRoot := filepath.Join(uploadDir, services.StagingDirName)
+	if err := os.MkdirAll(stagingRoot, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
 	}
 
+	stagingDir, err := os.MkdirTemp(stagingRoot, "upload-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &uploadBatch{
+		uploadDir:  uploadDir,
+		stagingDir: stagingDir,
+	}, nil
+}
+
+// Stage writes an uploaded file to the staging directory under the name it will have once committed
+func (b *uploadBatch) Stage(file multipart.File, header *multipart.FileHeader, prefix string) (*stagedUpload, error) {
 	// Generate unique filename
 	ext := filepath.Ext(header.Filename)
 	filename := fmt.Sprintf("%s_%s_%d%s", prefix, uuid.New().String(), time.Now().Unix(), ext)
-	filePath := filepath.Join(uploadDir, filename)
+	upload := &stagedUpload{
+		Path:      filepath.Join(b.stagingDir, filename),
+		FinalPath: filepath.Join(b.uploadDir, filename),
+	}
 
 	// Create destination file
-	dst, err := os.Create(filePath)
+	dst, err := os.Create(upload.Path)
 	if err != nil {
-		return "", fmt.Errorf("failed to create destination file: %w", err)
+		return nil, fmt.Errorf("failed to create destination file: %w", err)
 	}
 	defer dst.Close()
 
-	// Copy file content
+	// Copy file content; the staging directory is removed by Cleanup on error
 	if _, err := io.Copy(dst, file); err != nil {
-		// Clean up on error
-		os.Remove(filePath)
-		return "", fmt.Errorf("failed to copy file content: %w", err)
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+
+	if err := dst.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return filePath, nil
+	b.staged = append(b.staged, upload)
+
+	return upload, nil
+}
+
+// Commit moves every staged file into the upload directory; on failure nothing is left in place
+func (b *uploadBatch) Commit() error {
+	for _, upload := range b.staged {
+		if err := renameFile(upload.Path, upload.FinalPath); err != nil {
+			b.removeCommitted()
+			return fmt.Errorf("failed to store uploaded file: %w", err)
+		}
+		b.committed = append(b.committed, upload.FinalPath)
+	}
+
+	return nil
+}
+
+// Keep marks the committed files as owned by a stored exam, so Cleanup leaves them alone
+func (b *uploadBatch) Keep() {
+	b.kept = true
+}
+
+// Cleanup removes the staging directory and, unless Keep was called, any committed files
+func (b *uploadBatch) Cleanup() {
+	if !b.kept {
+		b.removeCommitted()
+	}
+
+	if err := os.RemoveAll(b.stagingDir); err != nil {
+		log.Printf("Failed to remove staging directory %s: %v", b.stagingDir, err)
+	}
+}
+
+// removeCommitted deletes the files already moved into the upload directory
+func (b *uploadBatch) removeCommitted() {
+	for _, path := range b.committed {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove uploaded file %s: %v", path, err)
+		}
+	}
+	b.committed = nil
 }
 
-// ServeFile serves uploaded files (for development/testing purposes)
-func ServeFile(uploadDir string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.File(filepath.Join(uploadDir, c.Param("filepath")))
+// checkUpload reads a staged answer key and verifies its content with check
+// Answer keys are parsed anyway, so the whole file is read; its size is already bounded by the configured maximum
+func checkUpload(upload *stagedUpload, check func(data []byte) error) error {
+	data, err := os.ReadFile(upload.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+
+	return check(data)
+}
+
+// respondUploadError reports a rejected upload with its error code, or a server error for anything else
+func respondUploadError(c *gin.Context, field string, err error) {
+	var uploadErr *services.UploadError
+	if errors.As(err, &uploadErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid %s: %s", field, uploadErr.Message),
+			"code":  uploadErr.Code,
+			"field": field,
+		})
+		return
 	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to check %s: %v", field, err)})
+}
+
+// ServeFile serves uploaded exam PDFs (for development/testing purposes)
+// Only files stored as some exam's PDF are served; answer keys go through the exam's answer key endpoint, which checks access
+func (h *ExamHandler) ServeFile(c *gin.Context) {
+	name := filepath.Clean("/" + c.Param("filepath"))
+	path := filepath.Join(h.uploadDir, name)
+
+	known, err := h.examService.IsExamPDF(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to look up file: %v", err)})
+		return
+	}
+	if !known {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	c.File(path)
 }
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"exam-helper/internal/services"
+)
+
+// assertNoResidue fails unless the upload directory holds nothing but an empty staging directory
+func assertNoResidue(t *testing.T, uploadDir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(uploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != services.StagingDirName {
+			t.Errorf("%s left in the upload directory", entry.Name())
+		}
+	}
+
+	staged, err := os.ReadDir(filepath.Join(uploadDir, services.StagingDirName))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	for _, entry := range staged {
+		t.Errorf("%s left in the staging directory", entry.Name())
+	}
+}
+
+func TestCreateExamFailuresLeaveNoFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    map[string]string
+		examPDF   string
+		answerKey string
+		status    int
+	}{
+		{"exam is not a PDF", nil, "1. A\n", testAnswerKey, http.StatusBadRequest},
+		{"answer key is not text", nil, testPDF, "1. A\x00\x01", http.StatusBadRequest},
+		{"answer key has no answers", nil, testPDF, "nothing to see here\n", http.StatusBadRequest},
+		{"strict answer key has problems", map[string]string{"strict": "true"}, testPDF, "1. A\nnot an answer\n2. B\n", http.StatusBadRequest},
+		{"exam service rejects the exam", map[string]string{"scoring": "cespe", "options": "A-E"}, testPDF, testAnswerKey, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			fields := map[string]string{"mode": "stopwatch"}
+			for name, value := range tt.fields {
+				fields[name] = value
+			}
+
+			w := s.do(t, uploadForm(t, fields, map[string][2]string{
+				"exam_pdf":   {"prova.pdf", tt.examPDF},
+				"answer_key": {"gabarito.txt", tt.answerKey},
+			}))
+			if w.Code != tt.status {
+				t.Fatalf("status %d %s, want %d", w.Code, w.Body, tt.status)
+			}
+
+			assertNoResidue(t, s.uploadDir)
+		})
+	}
+}
+
+func TestCreateExamCommitFailureLeavesNoFiles(t *testing.T) {
+	// The second rename fails, so the file already moved into place must be removed again
+	renames := 0
+	renameFile = func(from, to string) error {
+		renames++
+		if renames == 2 {
+			return errors.New("disk full")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	s := newTestServer(t)
+	w := s.do(t, uploadForm(t, map[string]string{"mode": "stopwatch"}, map[string][2]string{
+		"exam_pdf":   {"prova.pdf", testPDF},
+		"answer_key": {"gabarito.txt", testAnswerKey},
+	}))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d %s, want 500", w.Code, w.Body)
+	}
+	if renames != 2 {
+		t.Fatalf("%d renames, want 2", renames)
+	}
+
+	assertNoResidue(t, s.uploadDir)
+}
+
+func TestCreateExamKeepsCommittedFiles(t *testing.T) {
+	s := newTestServer(t)
+	exam := s.createExam(t)
+
+	stored, err := s.service.GetExam(exam.Exam.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{stored.ExamPDFPath, stored.AnswerKeyPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("stored file %s: %v", path, err)
+		}
+	}
+
+	staged, _ := os.ReadDir(filepath.Join(s.uploadDir, services.StagingDirName))
+	if len(staged) != 0 {
+		t.Errorf("%d entries left in the staging directory", len(staged))
+	}
+}
+
+func TestCreateExamRejectsOversizedUploads(t *testing.T) {
+	// A PDF body padded after the header; the size checks run before its content is looked at
+	oversized := func(size int) string {
+		return testPDF + strings.Repeat(" ", size-len(testPDF))
+	}
+
+	tests := []struct {
+		name    string
+		examPDF string
+		field   string
+	}{
+		// Both files together may use twice the file limit plus the form allowance
+		{"request over the cap", oversized(3*testMaxFileSize + formOverhead), ""},
+		{"file over the limit", oversized(testMaxFileSize + 1), "exam_pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			w := s.do(t, uploadForm(t, map[string]string{"mode": "stopwatch"}, map[string][2]string{
+				"exam_pdf":   {"prova.pdf", tt.examPDF},
+				"answer_key": {"gabarito.txt", testAnswerKey},
+			}))
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status %d %s, want 413", w.Code, w.Body)
+			}
+
+			var body struct {
+				Code  string `json:"code"`
+				Field string `json:"field"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != services.UploadTooLarge || body.Field != tt.field {
+				t.Fatalf("code %q for field %q, want %q for field %q", body.Code, body.Field, services.UploadTooLarge, tt.field)
+			}
+
+			assertNoResidue(t, s.uploadDir)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnswerKey is an answer key parsed once at upload time and stored with its exam
+type AnswerKey struct {
+	Questions  []AnswerKeyQuestion `json:"questions"` // Ordered by question number
+	OptionSet  OptionSet           `json:"option_set"`
+	Sections   []AnswerKeySection  `json:"sections,omitempty"` // In order of appearance
+	Checksum   string              `json:"checksum"`           // SHA-256 of the uploaded file
+	SourceFile string              `json:"source_file"`
+	ParsedAt   time.Time           `json:"parsed_at"`
+}
+
+// AnswerKeyQuestion is a single entry of an answer key
+type AnswerKeyQuestion struct {
+	Number   int      `json:"number"`
+	Answer   string   `json:"answer"`
+	Weight   float64  `json:"weight"`
+	Section  string   `json:"section,omitempty"`
+	Annulled bool     `json:"annulled,omitempty"` // Everyone gets the point
+	Accepted []string `json:"accepted,omitempty"` // Every accepted option when a revision allows more than one, e.g. "12. B/D"
+}
+
+// AnnulledAnswer is the answer recorded for annulled questions
+const AnnulledAnswer = "X"
+
+// IsAnnulledMarker reports whether an answer key token marks a question as annulled, e.g. "X" or "ANULADA"
+func IsAnnulledMarker(token string) bool {
+	switch strings.ToUpper(strings.TrimSpace(token)) {
+	case AnnulledAnswer, "ANULADA", "ANULADO", "NULA", "NULO":
+		return true
+	}
+
+	return false
+}
+
+// AnswerKeySection is a "## Name weight=N" header grouping the questions that follow it
+type AnswerKeySection struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// EffectiveWeight returns the question weight, treating keys stored before weights existed as weight 1
+func (q AnswerKeyQuestion) EffectiveWeight() float64 {
+	if q.Weight <= 0 {
+		return 1
+	}
+
+	return q.Weight
+}
+
+// AcceptedAnswers returns every option that counts as correct for the question
+func (q AnswerKeyQuestion) AcceptedAnswers() []string {
+	if len(q.Accepted) > 0 {
+		return q.Accepted
+	}
+
+	return []string{q.Answer}
+}
+
+// Accepts reports whether a normalized option is a correct answer for the question
+func (q AnswerKeyQuestion) Accepts(option string) bool {
+	if option == "" {
+		return false
+	}
+
+	for _, accepted := range q.AcceptedAnswers() {
+		if option == accepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AnswerText returns the accepted options joined for display, e.g. "B/D"
+func (q AnswerKeyQuestion) AnswerText() string {
+	return strings.Join(q.AcceptedAnswers(), "/")
+}
+
+// DiagnosticSeverity classifies a parse diagnostic
+type DiagnosticSeverity string
+
+const (
+	SeverityInfo    DiagnosticSeverity = "info"
+	SeverityWarning DiagnosticSeverity = "warning"
+	SeverityError   DiagnosticSeverity = "error"
+)
+
+// ParseDiagnostic describes a problem found while parsing an answer key
+type ParseDiagnostic struct {
+	Line     int                `json:"line,omitempty"` // 1-based source line; omitted for key-wide findings
+	Text     string             `json:"text,omitempty"` // Raw line content
+	Reason   string             `json:"reason"`
+	Severity DiagnosticSeverity `json:"severity"`
+}
+
+// Map returns the key as question number to answer, the shape used by results
+func (k *AnswerKey) Map() map[string]string {
+	m := make(map[string]string, len(k.Questions))
+	for _, q := range k.Questions {
+		m[strconv.Itoa(q.Number)] = q.AnswerText()
+	}
+
+	return m
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"time"
 )
 
@@ -20,27 +23,58 @@ const (
 	StatusActive    ExamStatus = "active"
 	StatusCompleted ExamStatus = "completed"
 	StatusExpired   ExamStatus = "expired"
+	StatusArchived  ExamStatus = "archived" // Hidden from use and purged after the retention period
+)
+
+// SubmissionOutcome records how a submission related to the exam deadline
+type SubmissionOutcome string
+
+const (
+	SubmissionOnTime        SubmissionOutcome = "on_time"
+	SubmissionGracePeriod   SubmissionOutcome = "grace_period"   // After the deadline but within the grace period
+	SubmissionLate          SubmissionOutcome = "late"           // After the grace period, accepted and flagged
+	SubmissionLateAutosaved SubmissionOutcome = "late_autosaved" // After the grace period, graded from stored answers
+	SubmissionExpired       SubmissionOutcome = "expired"        // Never submitted; graded from stored answers when time ran out
 )
 
 // Exam represents an exam session
 type Exam struct {
-	ID           string            `json:"id"`
-	Mode         ExamMode          `json:"mode"`
-	Status       ExamStatus        `json:"status"`
-	ExamPDFPath  string            `json:"exam_pdf_path"`
-	AnswerKeyPath string           `json:"answer_key_path"`
-	Duration     *time.Duration    `json:"duration,omitempty"` // Only for timer mode
-	StartTime    *time.Time        `json:"start_time,omitempty"`
-	EndTime      *time.Time        `json:"end_time,omitempty"`
-	Answers      map[string]string `json:"answers"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID             string            `json:"id"`
+	Mode           ExamMode          `json:"mode"`
+	Status         ExamStatus        `json:"status"`
+	ExamPDFPath    string            `json:"exam_pdf_path"`
+	AnswerKeyPath  string            `json:"answer_key_path"`
+	AnswerKey      *AnswerKey        `json:"answer_key,omitempty"`
+	KeyRevision    int               `json:"key_revision"` // 0 for the original key, incremented by every revised key
+	OptionSet      OptionSet         `json:"option_set"`
+	Scoring        ScoringConfig     `json:"scoring"`
+	Duration       *time.Duration    `json:"duration,omitempty"` // Only for timer mode
+	StartTime      *time.Time        `json:"start_time,omitempty"`
+	EndTime        *time.Time        `json:"end_time,omitempty"`
+	Answers        map[string]string `json:"answers"`
+	AnswersVersion int               `json:"answers_version"`            // Incremented on every autosave
+	Result         *ExamResult       `json:"result,omitempty"`           // Set once the exam is completed or expired
+	ResultHistory  []*ExamResult     `json:"result_history,omitempty"`   // Results replaced by re-grading, oldest first
+	Owner          string            `json:"owner,omitempty"`            // Free-form label of who created the exam
+	OwnerTokenHash string            `json:"owner_token_hash,omitempty"` // SHA-256 of the token returned at creation
+	ArchivedAt     *time.Time        `json:"archived_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 // CreateExamRequest represents the request to create a new exam
 type CreateExamRequest struct {
 	Mode     ExamMode       `json:"mode" binding:"required,oneof=timer stopwatch"`
 	Duration *time.Duration `json:"duration,omitempty"` // Required for timer mode
+	Scoring  ScoringConfig  `json:"scoring"`            // Zero value means percentage
+	Owner    string         `json:"owner,omitempty"`
+}
+
+// SaveAnswersRequest represents a partial autosave of answers
+// Answers are merged into the stored ones; an empty answer clears that question
+type SaveAnswersRequest struct {
+	Answers map[string]string `json:"answers" binding:"required"`
+	Version *int              `json:"version" binding:"required"` // Version the client last saw
 }
 
 // SubmitAnswersRequest represents the request to submit answers
@@ -50,21 +84,112 @@ type SubmitAnswersRequest struct {
 
 // ExamResult represents the result of an exam
 type ExamResult struct {
-	ExamID        string            `json:"exam_id"`
-	TotalQuestions int              `json:"total_questions"`
-	CorrectAnswers int              `json:"correct_answers"`
-	WrongAnswers   int              `json:"wrong_answers"`
-	Score          float64          `json:"score"`
-	TimeTaken      time.Duration    `json:"time_taken"`
-	Answers        map[string]string `json:"answers"`
-	CorrectKey     map[string]string `json:"correct_key"`
-	Details        []QuestionResult  `json:"details"`
+	ExamID            string            `json:"exam_id"`
+	TotalQuestions    int               `json:"total_questions"`
+	CorrectAnswers    int               `json:"correct_answers"`
+	WrongAnswers      int               `json:"wrong_answers"` // Answered incorrectly; blanks are counted separately
+	BlankAnswers      int               `json:"blank_answers"`
+	Score             float64           `json:"score"`          // Percentage of the maximum score
+	NetScore          float64           `json:"net_score"`      // Points after penalties, e.g. correct minus wrong for cespe
+	MaxScore          float64           `json:"max_score"`      // Points for a perfect exam
+	WeightedScore     float64           `json:"weighted_score"` // Sum of the weights of correct answers
+	WeightedTotal     float64           `json:"weighted_total"` // Sum of all question weights
+	Sections          []SectionResult   `json:"sections,omitempty"`
+	Scoring           ScoringConfig     `json:"scoring"` // Scheme and parameters used to compute the score
+	TimeTaken         time.Duration     `json:"time_taken"`
+	Answers           map[string]string `json:"answers"`
+	CorrectKey        map[string]string `json:"correct_key"`
+	Details           []QuestionResult  `json:"details"`
+	SubmittedAt       time.Time         `json:"submitted_at"`
+	Submission        SubmissionOutcome `json:"submission"`
+	LateBy            time.Duration     `json:"late_by,omitempty"` // Time past the deadline, if any
+	GradedAt          time.Time         `json:"graded_at"`
+	AnnulledQuestions int               `json:"annulled_questions"`
+	AnswerKeyChecksum string            `json:"answer_key_checksum"` // SHA-256 of the answer key used for grading
+	AnswerKeyRevision int               `json:"answer_key_revision"` // Key revision used for grading
 }
 
 // QuestionResult represents the result for a single question
 type QuestionResult struct {
-	QuestionNumber string `json:"question_number"`
-	UserAnswer     string `json:"user_answer"`
-	CorrectAnswer  string `json:"correct_answer"`
-	IsCorrect      bool   `json:"is_correct"`
+	QuestionNumber  string   `json:"question_number"`
+	UserAnswer      string   `json:"user_answer"`
+	CorrectAnswer   string   `json:"correct_answer"` // Accepted options joined with "/" when there is more than one
+	AcceptedAnswers []string `json:"accepted_answers"`
+	IsCorrect       bool     `json:"is_correct"`
+	IsBlank         bool     `json:"is_blank"`
+	IsAnnulled      bool     `json:"is_annulled,omitempty"`
+	Weight          float64  `json:"weight"`
+	Section         string   `json:"section,omitempty"`
+}
+
+// SectionResult holds the subtotals of one answer key section
+type SectionResult struct {
+	Name           string  `json:"name"`
+	TotalQuestions int     `json:"total_questions"`
+	CorrectAnswers int     `json:"correct_answers"`
+	WrongAnswers   int     `json:"wrong_answers"`
+	BlankAnswers   int     `json:"blank_answers"`
+	WeightedScore  float64 `json:"weighted_score"`
+	WeightedTotal  float64 `json:"weighted_total"`
+}
+
+// Deadline returns when a timer exam must end; ok is false for exams without a deadline
+func (e *Exam) Deadline() (deadline time.Time, ok bool) {
+	if e.Mode != ModeTimer || e.Duration == nil || e.StartTime == nil {
+		return time.Time{}, false
+	}
+
+	return e.StartTime.Add(*e.Duration), true
+}
+
+// Options returns the exam's option set, falling back to A–E for exams created before option sets existed
+func (e *Exam) Options() OptionSet {
+	if len(e.OptionSet.Options) == 0 {
+		return DefaultOptionSet()
+	}
+
+	return e.OptionSet
+}
+
+// HasEnded reports whether the exam was completed or expired
+// Archived exams count as ended once they have a result
+func (e *Exam) HasEnded() bool {
+	switch e.Status {
+	case StatusCompleted, StatusExpired:
+		return true
+	case StatusArchived:
+		return e.Result != nil
+	}
+
+	return false
+}
+
+// IsOwner reports whether token is the owner token issued when the exam was created
+func (e *Exam) IsOwner(token string) bool {
+	if e.OwnerTokenHash == "" || token == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(HashOwnerToken(token)), []byte(e.OwnerTokenHash)) == 1
+}
+
+// CanViewAnswerKey reports whether the answer key may be shown to the holder of token
+// Examinees only see it once the exam has ended
+func (e *Exam) CanViewAnswerKey(token string) bool {
+	return e.HasEnded() || e.IsOwner(token)
+}
+
+// HashOwnerToken returns the hex SHA-256 of an owner token, the form stored with the exam
+func HashOwnerToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Redacted returns a copy of the exam that is safe to send to examinees, without the answer key
+func (e *Exam) Redacted() *Exam {
+	redacted := *e
+	redacted.AnswerKey = nil
+	redacted.OwnerTokenHash = ""
+
+	return &redacted
 }
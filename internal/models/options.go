@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Predefined option set names accepted at exam creation
+const (
+	OptionSetAD     = "A-D"
+	OptionSetAE     = "A-E"
+	OptionSetCE     = "C/E" // Certo/Errado
+	OptionSetVF     = "V/F" // Verdadeiro/Falso
+	OptionSetCustom = "custom"
+)
+
+var optionPattern = regexp.MustCompile(`^[A-Z0-9]{1,8}$`)
+
+// OptionSet is the list of answer options valid for every question of an exam
+type OptionSet struct {
+	Name    string            `json:"name"`
+	Options []string          `json:"options"`
+	Aliases map[string]string `json:"aliases,omitempty"` // Spelled-out answers mapped to their option, e.g. CERTO -> C
+}
+
+// DefaultOptionSet returns the A–E set used when an exam does not choose one
+func DefaultOptionSet() OptionSet {
+	set, _ := ParseOptionSet(OptionSetAE)
+	return set
+}
+
+// ParseOptionSet resolves a predefined set name or a comma-separated custom list such as "A,B,C"
+func ParseOptionSet(value string) (OptionSet, error) {
+	value = strings.TrimSpace(value)
+
+	switch strings.ToUpper(value) {
+	case "", OptionSetAE:
+		return OptionSet{Name: OptionSetAE, Options: []string{"A", "B", "C", "D", "E"}}, nil
+	case OptionSetAD:
+		return OptionSet{Name: OptionSetAD, Options: []string{"A", "B", "C", "D"}}, nil
+	case OptionSetCE:
+		return OptionSet{
+			Name:    OptionSetCE,
+			Options: []string{"C", "E"},
+			Aliases: map[string]string{"CERTO": "C", "ERRADO": "E"},
+		}, nil
+	case OptionSetVF:
+		return OptionSet{
+			Name:    OptionSetVF,
+			Options: []string{"V", "F"},
+			Aliases: map[string]string{"VERDADEIRO": "V", "FALSO": "F"},
+		}, nil
+	}
+
+	if !strings.Contains(value, ",") {
+		return OptionSet{}, fmt.Errorf("unknown option set %q. Expected %s, %s, %s, %s or a comma-separated list", value, OptionSetAD, OptionSetAE, OptionSetCE, OptionSetVF)
+	}
+
+	set := OptionSet{Name: OptionSetCustom}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		option := strings.ToUpper(strings.TrimSpace(part))
+		if !optionPattern.MatchString(option) {
+			return OptionSet{}, fmt.Errorf("invalid option %q. Options must be 1 to 8 letters or digits", part)
+		}
+		if seen[option] {
+			return OptionSet{}, fmt.Errorf("option %q is listed more than once", option)
+		}
+		seen[option] = true
+		set.Options = append(set.Options, option)
+	}
+
+	if len(set.Options) < 2 {
+		return OptionSet{}, fmt.Errorf("an option set needs at least two options")
+	}
+
+	return set, nil
+}
+
+// Normalize maps a raw answer to one of the set's options; ok is false for anything else
+func (o OptionSet) Normalize(answer string) (option string, ok bool) {
+	answer = strings.ToUpper(strings.TrimSpace(answer))
+
+	if alias, exists := o.Aliases[answer]; exists {
+		return alias, true
+	}
+
+	for _, option := range o.Options {
+		if option == answer {
+			return option, true
+		}
+	}
+
+	return "", false
+}
+
+// String lists the options for error messages, e.g. "A, B, C, D or E"
+func (o OptionSet) String() string {
+	if len(o.Options) < 2 {
+		return strings.Join(o.Options, "")
+	}
+
+	return strings.Join(o.Options[:len(o.Options)-1], ", ") + " or " + o.Options[len(o.Options)-1]
+}
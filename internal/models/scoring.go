@@ -0,0 +1,20 @@
+package models
+
+// ScoringScheme selects how an exam is scored
+type ScoringScheme string
+
+const (
+	ScoringPercentage        ScoringScheme = "percentage"          // One point per correct answer
+	ScoringNegative          ScoringScheme = "negative"            // One point per correct answer, minus Penalty per wrong answer
+	ScoringCespe             ScoringScheme = "cespe"               // Certo/Errado: +1 correct, -1 wrong, 0 blank
+	ScoringWeighted          ScoringScheme = "weighted"            // Each correct answer is worth its question weight
+	ScoringWrongCancelsRight ScoringScheme = "wrong_cancels_right" // Every WrongsPerRight wrong answers cancel one correct answer
+)
+
+// ScoringConfig is the scoring scheme chosen at exam creation, together with its parameters
+type ScoringConfig struct {
+	Scheme         ScoringScheme      `json:"scheme"`
+	Penalty        float64            `json:"penalty,omitempty"`          // negative: points lost per wrong answer, e.g. 0.25
+	WrongsPerRight int                `json:"wrongs_per_right,omitempty"` // wrong_cancels_right: wrong answers that cancel one correct answer
+	Weights        map[string]float64 `json:"weights,omitempty"`          // weighted: points per question number; unlisted questions are worth 1
+}
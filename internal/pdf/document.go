@@ -0,0 +1,375 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrNotPDF is returned when the data does not start with a PDF header
+var ErrNotPDF = errors.New("not a PDF document")
+
+// ErrEncrypted is returned for documents protected by a security handler
+var ErrEncrypted = errors.New("encrypted PDF documents are not supported")
+
+// ErrNoPages is returned when the page tree cannot be located
+var ErrNoPages = errors.New("PDF document has no pages")
+
+// ErrMalformed is returned when the document structure is too damaged to read
+var ErrMalformed = errors.New("malformed PDF document")
+
+// SniffSize is how much of the start and of the end of a file Sniff looks at
+const SniffSize = 64 << 10
+
+var objectHeaderPattern = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// Document is a parsed PDF file with all of its indirect objects loaded
+type Document struct {
+	objects map[int]Object
+	trailer Dict
+}
+
+// Open parses a PDF document from its raw bytes
+func Open(data []byte) (*Document, error) {
+	if !HasHeader(data) {
+		return nil, ErrNotPDF
+	}
+
+	doc := &Document{objects: make(map[int]Object)}
+	doc.loadObjects(data)
+	doc.loadObjectStreams()
+
+	if err := doc.loadTrailer(data); err != nil {
+		return nil, err
+	}
+
+	if _, encrypted := doc.trailer["Encrypt"]; encrypted {
+		return nil, ErrEncrypted
+	}
+
+	return doc, nil
+}
+
+// Validate checks that data is a readable, unencrypted PDF document with at least one page
+func Validate(data []byte) error {
+	if !HasHeader(data) {
+		return ErrNotPDF
+	}
+
+	if !HasTrailer(data) {
+		return fmt.Errorf("%w: missing end-of-file marker", ErrMalformed)
+	}
+
+	doc, err := Open(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = doc.Pages()
+	return err
+}
+
+// Sniff checks the start and the end of a file for a PDF header, an end-of-file marker and encryption
+// The body is never parsed, so it is cheap and safe on untrusted files of any size
+func Sniff(head, tail []byte) error {
+	if !HasHeader(head) {
+		return ErrNotPDF
+	}
+
+	if !HasTrailer(tail) {
+		return fmt.Errorf("%w: missing end-of-file marker", ErrMalformed)
+	}
+
+	// The trailer sits at the end, or near the start of a linearized file
+	if hasEncryptEntry(head) || hasEncryptEntry(tail) {
+		return ErrEncrypted
+	}
+
+	return nil
+}
+
+// hasEncryptEntry reports whether data contains an /Encrypt name token
+func hasEncryptEntry(data []byte) bool {
+	token := []byte("/Encrypt")
+	for {
+		idx := bytes.Index(data, token)
+		if idx < 0 {
+			return false
+		}
+		end := idx + len(token)
+		if end == len(data) || !isRegular(data[end]) {
+			return true
+		}
+		data = data[end:]
+	}
+}
+
+// HasTrailer reports whether data ends with an end-of-file marker, allowing trailing junk as readers do
+func HasTrailer(data []byte) bool {
+	start := len(data) - 1024
+	if start < 0 {
+		start = 0
+	}
+	return bytes.Contains(data[start:], []byte("%%EOF"))
+}
+
+// HasHeader reports whether data starts with a PDF header, allowing leading junk as readers do
+func HasHeader(data []byte) bool {
+	limit := len(data)
+	if limit > 1024 {
+		limit = 1024
+	}
+	return bytes.Contains(data[:limit], []byte("%PDF-"))
+}
+
+// loadObjects scans the file for "n g obj" headers; later definitions win, matching incremental updates
+func (d *Document) loadObjects(data []byte) {
+	for _, loc := range objectHeaderPattern.FindAllSubmatchIndex(data, -1) {
+		// Make sure the match starts on a token boundary
+		if loc[0] > 0 && isRegular(data[loc[0]-1]) {
+			continue
+		}
+
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		lex := &lexer{data: data, pos: loc[1], refs: true}
+
+		obj, err := lex.next()
+		if err != nil {
+			continue
+		}
+
+		if dict, ok := obj.(Dict); ok {
+			lex.skipSpace()
+			if bytes.HasPrefix(data[lex.pos:], []byte("stream")) {
+				obj = &Stream{Dict: dict, Data: readStreamData(data, lex.pos+len("stream"), dict)}
+			}
+		}
+
+		d.objects[num] = obj
+	}
+}
+
+// readStreamData returns the raw bytes between "stream" and "endstream"
+func readStreamData(data []byte, pos int, dict Dict) []byte {
+	// The stream keyword is followed by CRLF or LF
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+
+	// Trust a direct /Length when it lines up with endstream
+	if length, ok := dict["Length"].(int); ok && length >= 0 && pos+length <= len(data) {
+		rest := bytes.TrimLeft(data[pos+length:], "\r\n \t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return data[pos : pos+length]
+		}
+	}
+
+	// Indirect or wrong length: fall back to scanning for the end marker
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:]
+	}
+
+	raw := data[pos : pos+end]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return raw
+}
+
+// loadObjectStreams unpacks objects compressed inside /Type /ObjStm streams
+func (d *Document) loadObjectStreams() {
+	for _, obj := range d.objects {
+		stream, ok := obj.(*Stream)
+		if !ok || stream.Dict["Type"] != Name("ObjStm") {
+			continue
+		}
+
+		data, err := d.Decode(stream)
+		if err != nil {
+			continue
+		}
+
+		n, _ := d.Resolve(stream.Dict["N"]).(int)
+		first, _ := d.Resolve(stream.Dict["First"]).(int)
+		if first < 0 || first > len(data) {
+			continue
+		}
+
+		header := &lexer{data: data[:first]}
+		for i := 0; i < n; i++ {
+			numObj, err := header.next()
+			if err != nil {
+				break
+			}
+			offObj, err := header.next()
+			if err != nil {
+				break
+			}
+
+			num, ok1 := numObj.(int)
+			off, ok2 := offObj.(int)
+			if !ok1 || !ok2 || off < 0 || off >= len(data)-first {
+				continue
+			}
+
+			// Objects stored directly in the file take precedence
+			if _, exists := d.objects[num]; exists {
+				continue
+			}
+
+			body := &lexer{data: data, pos: first + off, refs: true}
+			if value, err := body.next(); err == nil {
+				d.objects[num] = value
+			}
+		}
+	}
+}
+
+// loadTrailer finds the trailer dictionary, either a classic trailer or a cross-reference stream
+func (d *Document) loadTrailer(data []byte) error {
+	if idx := bytes.LastIndex(data, []byte("trailer")); idx >= 0 {
+		lex := &lexer{data: data, pos: idx + len("trailer"), refs: true}
+		if obj, err := lex.next(); err == nil {
+			if dict, ok := obj.(Dict); ok {
+				d.trailer = dict
+			}
+		}
+	}
+
+	if d.trailer == nil || d.trailer["Root"] == nil {
+		for _, obj := range d.objects {
+			if stream, ok := obj.(*Stream); ok && stream.Dict["Type"] == Name("XRef") && stream.Dict["Root"] != nil {
+				d.trailer = stream.Dict
+				break
+			}
+		}
+	}
+
+	if d.trailer == nil || d.trailer["Root"] == nil {
+		// Damaged file: look for the catalog directly
+		for num, obj := range d.objects {
+			if dict, ok := obj.(Dict); ok && dict["Type"] == Name("Catalog") {
+				if d.trailer == nil {
+					d.trailer = Dict{}
+				}
+				d.trailer["Root"] = Ref{Num: num}
+				break
+			}
+		}
+	}
+
+	if d.trailer == nil || d.trailer["Root"] == nil {
+		return fmt.Errorf("%w: no document catalog", ErrMalformed)
+	}
+
+	return nil
+}
+
+// Resolve follows indirect references until it reaches a direct object
+func (d *Document) Resolve(obj Object) Object {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[ref.Num]
+	}
+	return nil
+}
+
+// dict resolves obj and returns it as a dictionary, using a stream's dictionary when needed
+func (d *Document) dict(obj Object) Dict {
+	switch v := d.Resolve(obj).(type) {
+	case Dict:
+		return v
+	case *Stream:
+		return v.Dict
+	}
+	return nil
+}
+
+// Pages returns the page dictionaries in document order, with inherited resources applied
+func (d *Document) Pages() ([]Dict, error) {
+	root := d.dict(d.trailer["Root"])
+	if root == nil {
+		return nil, ErrNoPages
+	}
+
+	var pages []Dict
+	visited := make(map[Ref]bool)
+
+	var walk func(node Object, resources Object)
+	walk = func(node Object, resources Object) {
+		if ref, ok := node.(Ref); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+
+		if r, ok := dict["Resources"]; ok {
+			resources = r
+		}
+
+		if kids, ok := d.Resolve(dict["Kids"]).(Array); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+			return
+		}
+
+		page := Dict{}
+		for k, v := range dict {
+			page[k] = v
+		}
+		if resources != nil {
+			page["Resources"] = resources
+		}
+		pages = append(pages, page)
+	}
+
+	walk(root["Pages"], nil)
+
+	if len(pages) == 0 {
+		return nil, ErrNoPages
+	}
+
+	return pages, nil
+}
+
+// pageContent returns the decoded, concatenated content streams of a page
+func (d *Document) pageContent(page Dict) ([]byte, error) {
+	var streams []*Stream
+	switch v := d.Resolve(page["Contents"]).(type) {
+	case *Stream:
+		streams = append(streams, v)
+	case Array:
+		for _, item := range v {
+			if s, ok := d.Resolve(item).(*Stream); ok {
+				streams = append(streams, s)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, s := range streams {
+		data, err := d.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode content stream: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
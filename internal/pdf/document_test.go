@@ -0,0 +1,92 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// objectStreamPDF builds a document whose only object stream has the given /First and header
+func objectStreamPDF(first int, header string) []byte {
+	body := header + "<</Type /Catalog>>"
+	return []byte(fmt.Sprintf("%%PDF-1.5\n"+
+		"5 0 obj\n<</Type /ObjStm /N 1 /First %d /Length %d>>\nstream\n%s\nendstream\nendobj\n"+
+		"trailer\n<</Root 3 0 R>>\n%%%%EOF\n", first, len(body), body))
+}
+
+func TestObjectStreamBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  int
+		header string
+	}{
+		{"negative first", -1, "3 0 "},
+		{"first past end", 1000, "3 0 "},
+		{"negative offset", 5, "3 -9 "},
+		{"offset past end", 5, "3 9999 "},
+		{"offset overflows", 5, "3 9223372036854775807 "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Open(objectStreamPDF(tt.first, tt.header))
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if _, ok := doc.objects[3]; ok {
+				t.Fatal("object 3 should not have been loaded from an out-of-range offset")
+			}
+		})
+	}
+}
+
+func TestObjectStreamLoadsObjects(t *testing.T) {
+	doc, err := Open(objectStreamPDF(5, "3 0  "))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	catalog, ok := doc.objects[3].(Dict)
+	if !ok || catalog["Type"] != Name("Catalog") {
+		t.Fatalf("object 3 = %v, want the catalog", doc.objects[3])
+	}
+}
+
+func TestSniff(t *testing.T) {
+	valid, err := os.ReadFile(filepath.Join("testdata", "flate.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sniff never looks inside the body, so a hostile one passes as long as the ends look right
+	hostile := append([]byte("%PDF-1.4\n1 0 obj\n"), bytes.Repeat([]byte("["), 1<<20)...)
+	hostile = append(hostile, "\n%%EOF\n"...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"valid", valid, nil},
+		{"hostile body", hostile, nil},
+		{"not a PDF", []byte("1 - A\n2 - B\n"), ErrNotPDF},
+		{"truncated", valid[:len(valid)/2], ErrMalformed},
+		{"encrypted", []byte("%PDF-1.4\ntrailer\n<</Root 1 0 R /Encrypt 2 0 R>>\n%%EOF\n"), ErrEncrypted},
+		{"encrypt name prefix", []byte("%PDF-1.4\n<</EncryptLater true>>\n%%EOF\n"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Sniff(tt.data, tt.data)
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
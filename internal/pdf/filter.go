@@ -0,0 +1,231 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxDecodedSize caps the size of a single decoded stream, far above any page of text
+const maxDecodedSize = 32 << 20
+
+var errStreamTooLarge = fmt.Errorf("decoded stream exceeds %d bytes", maxDecodedSize)
+
+// Decode applies the stream's filter chain and returns the decoded data
+func (d *Document) Decode(s *Stream) ([]byte, error) {
+	var filters []Name
+	var params []Dict
+
+	switch f := d.Resolve(s.Dict["Filter"]).(type) {
+	case Name:
+		filters = []Name{f}
+		params = []Dict{d.dict(s.Dict["DecodeParms"])}
+	case Array:
+		rawParams, _ := d.Resolve(s.Dict["DecodeParms"]).(Array)
+		for i, item := range f {
+			name, ok := d.Resolve(item).(Name)
+			if !ok {
+				return nil, fmt.Errorf("invalid filter: %v", item)
+			}
+			filters = append(filters, name)
+
+			var p Dict
+			if i < len(rawParams) {
+				p = d.dict(rawParams[i])
+			}
+			params = append(params, p)
+		}
+	}
+
+	data := s.Data
+	for i, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+			if err == nil {
+				data, err = d.unpredict(data, params[i])
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported filter: %s", filter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filter, err)
+		}
+	}
+
+	return data, nil
+}
+
+// inflate decompresses zlib data, tolerating raw deflate streams and truncated checksums
+func inflate(data []byte) ([]byte, error) {
+	if r, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		out, err := readLimited(r)
+		if errors.Is(err, errStreamTooLarge) {
+			return nil, err
+		}
+		if err == nil || len(out) > 0 {
+			return out, nil
+		}
+	}
+
+	out, err := readLimited(flate.NewReader(bytes.NewReader(data)))
+	if errors.Is(err, errStreamTooLarge) || (err != nil && len(out) == 0) {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// readLimited reads r to the end, failing instead of growing past maxDecodedSize
+func readLimited(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, maxDecodedSize+1))
+	if len(out) > maxDecodedSize {
+		return nil, errStreamTooLarge
+	}
+	return out, err
+}
+
+// unpredict reverses PNG predictors (Predictor >= 10) used mostly by cross-reference and object streams
+func (d *Document) unpredict(data []byte, params Dict) ([]byte, error) {
+	if params == nil {
+		return data, nil
+	}
+
+	predictor, _ := d.Resolve(params["Predictor"]).(int)
+	if predictor < 10 || len(data) == 0 {
+		return data, nil
+	}
+
+	columns, _ := d.Resolve(params["Columns"]).(int)
+	if columns <= 0 {
+		columns = 1
+	}
+	colors, _ := d.Resolve(params["Colors"]).(int)
+	if colors <= 0 {
+		colors = 1
+	}
+	bits, _ := d.Resolve(params["BitsPerComponent"]).(int)
+	if bits <= 0 {
+		bits = 8
+	}
+
+	// Check the parameters before multiplying them; a row can never be longer than the data itself
+	switch bits {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("invalid BitsPerComponent %d", bits)
+	}
+	if colors > 32 {
+		return nil, fmt.Errorf("invalid Colors %d", colors)
+	}
+	if columns > len(data) {
+		return nil, fmt.Errorf("invalid Columns %d for %d bytes of data", columns, len(data))
+	}
+
+	bpp := (colors*bits + 7) / 8
+	rowLen := (columns*colors*bits + 7) / 8
+	if rowLen >= len(data) {
+		return nil, fmt.Errorf("predictor row of %d bytes exceeds %d bytes of data", rowLen, len(data))
+	}
+	prev := make([]byte, rowLen)
+	var out bytes.Buffer
+
+	for pos := 0; pos+rowLen+1 <= len(data); pos += rowLen + 1 {
+		filterType := data[pos]
+		row := make([]byte, rowLen)
+		copy(row, data[pos+1:pos+1+rowLen])
+
+		for i := 0; i < rowLen; i++ {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+
+			switch filterType {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+
+		out.Write(row)
+		prev = row
+	}
+
+	return out.Bytes(), nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isWhitespace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return out[:n], nil
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"testing"
+)
+
+func deflate(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInflateLimit(t *testing.T) {
+	out, err := inflate(deflate(t, []byte("BT (Hello) Tj ET")))
+	if err != nil || string(out) != "BT (Hello) Tj ET" {
+		t.Fatalf("inflate = %q, %v", out, err)
+	}
+
+	bomb := deflate(t, make([]byte, maxDecodedSize+1))
+	if _, err := inflate(bomb); !errors.Is(err, errStreamTooLarge) {
+		t.Fatalf("got %v, want errStreamTooLarge", err)
+	}
+}
+
+func TestUnpredict(t *testing.T) {
+	doc := &Document{objects: map[int]Object{}}
+
+	// Two rows of two columns with the Up filter
+	data := []byte{2, 1, 2, 2, 1, 1}
+	out, err := doc.unpredict(data, Dict{"Predictor": 12, "Columns": 2})
+	if err != nil {
+		t.Fatalf("unpredict: %v", err)
+	}
+	if !bytes.Equal(out, []byte{1, 2, 2, 3}) {
+		t.Fatalf("unpredict = %v, want [1 2 2 3]", out)
+	}
+
+	invalid := []Dict{
+		{"Predictor": 12, "Columns": 1 << 62, "Colors": 1 << 30, "BitsPerComponent": 16},
+		{"Predictor": 12, "Columns": 1000},
+		{"Predictor": 12, "Columns": 2, "Colors": 1000},
+		{"Predictor": 12, "Columns": 2, "BitsPerComponent": 3},
+	}
+	for _, params := range invalid {
+		if _, err := doc.unpredict(data, params); err == nil {
+			t.Errorf("unpredict(%v) should have failed", params)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package pdf
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// font turns the bytes of a shown string into Unicode text
+type font struct {
+	// toUnicode maps source codes (as raw byte strings) to text, from a /ToUnicode CMap
+	toUnicode map[string]string
+	// codeLengths lists the code widths in bytes, longest first
+	codeLengths []int
+	// encoding maps single-byte codes for simple fonts
+	encoding *[256]rune
+}
+
+// newFont builds a decoder from a font dictionary
+func (d *Document) newFont(dict Dict) *font {
+	f := &font{codeLengths: []int{1}}
+
+	if d.Resolve(dict["Subtype"]) == Name("Type0") {
+		f.codeLengths = []int{2}
+	}
+
+	if s, ok := d.Resolve(dict["ToUnicode"]).(*Stream); ok {
+		if data, err := d.Decode(s); err == nil {
+			f.parseCMap(data)
+		}
+	}
+
+	f.encoding = d.simpleEncoding(dict["Encoding"])
+
+	return f
+}
+
+// simpleEncoding resolves a named encoding or an encoding dictionary with /Differences
+func (d *Document) simpleEncoding(obj Object) *[256]rune {
+	table := standardEncoding
+	var differences Array
+
+	switch enc := d.Resolve(obj).(type) {
+	case Name:
+		table = namedEncoding(enc)
+	case Dict:
+		if base, ok := d.Resolve(enc["BaseEncoding"]).(Name); ok {
+			table = namedEncoding(base)
+		}
+		differences, _ = d.Resolve(enc["Differences"]).(Array)
+	}
+
+	result := table
+	code := 0
+	for _, item := range differences {
+		switch v := d.Resolve(item).(type) {
+		case int:
+			code = v
+		case Name:
+			if code >= 0 && code < 256 {
+				if r, ok := glyphRune(string(v)); ok {
+					result[code] = r
+				}
+			}
+			code++
+		}
+	}
+
+	return &result
+}
+
+func namedEncoding(name Name) [256]rune {
+	switch name {
+	case "WinAnsiEncoding":
+		return winAnsiEncoding
+	case "MacRomanEncoding":
+		return macRomanEncoding
+	}
+	return standardEncoding
+}
+
+// parseCMap reads codespace ranges and bfchar/bfrange mappings from a ToUnicode CMap
+func (f *font) parseCMap(data []byte) {
+	lex := &lexer{data: data}
+	f.toUnicode = make(map[string]string)
+	lengths := make(map[int]bool)
+
+	var operands []Object
+	for {
+		obj, err := lex.next()
+		if err != nil {
+			break
+		}
+
+		kw, ok := obj.(Keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].(String); ok && len(lo) > 0 {
+					lengths[len(lo)] = true
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := operands[i].(String)
+				if !ok {
+					continue
+				}
+				if text, ok := cmapTarget(operands[i+1]); ok {
+					f.toUnicode[string(src)] = text
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				f.addRange(operands[i], operands[i+1], operands[i+2])
+			}
+		}
+
+		operands = operands[:0]
+	}
+
+	if len(lengths) > 0 {
+		f.codeLengths = f.codeLengths[:0]
+		for n := range lengths {
+			f.codeLengths = append(f.codeLengths, n)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(f.codeLengths)))
+	}
+}
+
+// addRange expands a bfrange entry; ranges are capped to keep hostile CMaps cheap
+func (f *font) addRange(loObj, hiObj, dst Object) {
+	lo, ok1 := loObj.(String)
+	hi, ok2 := hiObj.(String)
+	if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+		return
+	}
+
+	start, end := bytesToInt(lo), bytesToInt(hi)
+	if end < start || end-start > 0xFFFF {
+		return
+	}
+
+	switch target := dst.(type) {
+	case String:
+		base := []rune(decodeUTF16(target))
+		if len(base) == 0 {
+			return
+		}
+		for code := start; code <= end; code++ {
+			text := make([]rune, len(base))
+			copy(text, base)
+			text[len(text)-1] += rune(code - start)
+			f.toUnicode[string(intToBytes(code, len(lo)))] = string(text)
+		}
+	case Array:
+		for i, item := range target {
+			code := start + i
+			if code > end {
+				break
+			}
+			if text, ok := cmapTarget(item); ok {
+				f.toUnicode[string(intToBytes(code, len(lo)))] = text
+			}
+		}
+	}
+}
+
+func cmapTarget(obj Object) (string, bool) {
+	switch v := obj.(type) {
+	case String:
+		return decodeUTF16(v), true
+	case Name:
+		if r, ok := glyphRune(string(v)); ok {
+			return string(r), true
+		}
+	}
+	return "", false
+}
+
+func bytesToInt(b []byte) int {
+	v := 0
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+func intToBytes(v, n int) []byte {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = byte(v)
+		v >>= 8
+	}
+	return out
+}
+
+func decodeUTF16(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decode converts the bytes of a shown string into text
+func (f *font) decode(s []byte) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); {
+		matched := false
+
+		if f.toUnicode != nil {
+			for _, n := range f.codeLengths {
+				if i+n > len(s) {
+					continue
+				}
+				if text, ok := f.toUnicode[string(s[i:i+n])]; ok {
+					sb.WriteString(text)
+					i += n
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+
+		n := f.codeLengths[len(f.codeLengths)-1]
+		if n == 1 {
+			if r := f.encoding[s[i]]; r != 0 {
+				sb.WriteRune(r)
+			}
+			i++
+			continue
+		}
+
+		// Multi-byte code without a mapping; assume the code is the Unicode value
+		if i+n > len(s) {
+			break
+		}
+		if r := rune(bytesToInt(s[i : i+n])); r >= 0x20 {
+			sb.WriteRune(r)
+		}
+		i += n
+	}
+
+	return sb.String()
+}
+
+// glyphRune maps an Adobe glyph name to a rune, covering uniXXXX names and the Latin set
+func glyphRune(name string) (rune, bool) {
+	if r, ok := glyphNames[name]; ok {
+		return r, true
+	}
+	if len(name) == 1 {
+		return rune(name[0]), true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if v, err := strconv.ParseUint(name[3:], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	if strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7 {
+		if v, err := strconv.ParseUint(name[1:], 16, 32); err == nil {
+			return rune(v), true
+		}
+	}
+	return 0, false
+}
+
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "quoteright": '’', "quoteleft": '‘',
+	"parenleft": '(', "parenright": ')', "asterisk": '*', "plus": '+', "comma": ',',
+	"hyphen": '-', "minus": '-', "period": '.', "slash": '/', "colon": ':', "semicolon": ';',
+	"less": '<', "equal": '=', "greater": '>', "question": '?', "at": '@',
+	"bracketleft": '[', "backslash": '\\', "bracketright": ']', "underscore": '_',
+	"braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"endash": '–', "emdash": '—', "bullet": '•', "ordfeminine": 'ª', "ordmasculine": 'º',
+	"degree": '°', "section": '§', "quotedblleft": '“', "quotedblright": '”',
+	"Aacute": 'Á', "Agrave": 'À', "Acircumflex": 'Â', "Atilde": 'Ã', "Adieresis": 'Ä',
+	"aacute": 'á', "agrave": 'à', "acircumflex": 'â', "atilde": 'ã', "adieresis": 'ä',
+	"Eacute": 'É', "Egrave": 'È', "Ecircumflex": 'Ê', "Edieresis": 'Ë',
+	"eacute": 'é', "egrave": 'è', "ecircumflex": 'ê', "edieresis": 'ë',
+	"Iacute": 'Í', "Igrave": 'Ì', "Icircumflex": 'Î', "Idieresis": 'Ï',
+	"iacute": 'í', "igrave": 'ì', "icircumflex": 'î', "idieresis": 'ï',
+	"Oacute": 'Ó', "Ograve": 'Ò', "Ocircumflex": 'Ô', "Otilde": 'Õ', "Odieresis": 'Ö',
+	"oacute": 'ó', "ograve": 'ò', "ocircumflex": 'ô', "otilde": 'õ', "odieresis": 'ö',
+	"Uacute": 'Ú', "Ugrave": 'Ù', "Ucircumflex": 'Û', "Udieresis": 'Ü',
+	"uacute": 'ú', "ugrave": 'ù', "ucircumflex": 'û', "udieresis": 'ü',
+	"Ccedilla": 'Ç', "ccedilla": 'ç', "Ntilde": 'Ñ', "ntilde": 'ñ',
+	"fi": 'ﬁ', "fl": 'ﬂ',
+}
+
+// standardEncoding is the Adobe standard encoding restricted to the printable ASCII range
+var standardEncoding = func() [256]rune {
+	var t [256]rune
+	for c := 0x20; c < 0x7F; c++ {
+		t[c] = rune(c)
+	}
+	t['\''] = '’'
+	t['`'] = '‘'
+	return t
+}()
+
+// winAnsiEncoding is Windows code page 1252
+var winAnsiEncoding = func() [256]rune {
+	var t [256]rune
+	for c := 0x20; c < 0x7F; c++ {
+		t[c] = rune(c)
+	}
+	for c := 0xA0; c <= 0xFF; c++ {
+		t[c] = rune(c)
+	}
+	high := map[int]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	}
+	for c, r := range high {
+		t[c] = r
+	}
+	return t
+}()
+
+// macRomanEncoding is the classic Mac OS Roman character set
+var macRomanEncoding = func() [256]rune {
+	var t [256]rune
+	for c := 0x20; c < 0x7F; c++ {
+		t[c] = rune(c)
+	}
+	upper := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range upper {
+		t[0x80+i] = r
+	}
+	return t
+}()
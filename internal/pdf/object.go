@@ -0,0 +1,380 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Object is any PDF object: nil, bool, int, float64, Name, String, Array, Dict, Ref, *Stream or Keyword
+type Object interface{}
+
+// Name is a PDF name object such as /Type
+type Name string
+
+// String is a PDF literal or hexadecimal string, kept as raw bytes
+type String []byte
+
+// Array is a PDF array
+type Array []Object
+
+// Dict is a PDF dictionary
+type Dict map[Name]Object
+
+// Ref is an indirect object reference such as "12 0 R"
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// Stream is a dictionary followed by raw (still encoded) stream data
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+// Keyword is a bare token: an operator in a content stream or obj/endobj/stream in a file
+type Keyword string
+
+// maxNestingDepth bounds how deeply arrays and dictionaries may nest; real files stay in single digits
+const maxNestingDepth = 256
+
+var errEOF = errors.New("unexpected end of data")
+
+var errTooDeep = fmt.Errorf("objects nested deeper than %d levels", maxNestingDepth)
+
+// lexer tokenizes PDF syntax from a byte slice
+type lexer struct {
+	data []byte
+	pos  int
+	// refs enables "n g R" reference detection, which is disabled for content streams
+	refs bool
+	// depth counts the arrays and dictionaries currently being read
+	depth int
+}
+
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(c byte) bool {
+	return !isWhitespace(c) && !isDelimiter(c)
+}
+
+// skipSpace skips whitespace and comments
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next reads the next complete object, recursing into arrays and dictionaries
+func (l *lexer) next() (Object, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteralString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return l.readDict()
+		}
+		return l.readHexString()
+	case c == '[':
+		l.pos++
+		return l.readArray()
+	case c == ']' || c == '>' || c == '{' || c == '}' || c == ')':
+		l.pos++
+		if c == '>' && l.pos < len(l.data) && l.data[l.pos] == '>' {
+			l.pos++
+			return Keyword(">>"), nil
+		}
+		return Keyword(string(c)), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.readNumber()
+	default:
+		return l.readKeyword(), nil
+	}
+}
+
+func (l *lexer) readName() Name {
+	l.pos++ // skip '/'
+	var buf bytes.Buffer
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				buf.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		buf.WriteByte(c)
+		l.pos++
+	}
+	return Name(buf.String())
+}
+
+func (l *lexer) readKeyword() Object {
+	start := l.pos
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		// Unknown delimiter; consume it so the caller always makes progress
+		l.pos++
+	}
+
+	word := string(l.data[start:l.pos])
+	switch word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	return Keyword(word)
+}
+
+func (l *lexer) readNumber() (Object, error) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if (c >= '0' && c <= '9') || c == '.' {
+			l.pos++
+			continue
+		}
+		break
+	}
+
+	text := string(l.data[start:l.pos])
+	if !bytes.ContainsRune([]byte(text), '.') {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			// A lone sign or malformed integer; treat as zero like most readers do
+			return 0, nil
+		}
+		if l.refs && n >= 0 {
+			if ref, ok := l.tryRef(n); ok {
+				return ref, nil
+			}
+		}
+		return n, nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0.0, nil
+	}
+	return f, nil
+}
+
+// tryRef looks ahead for "gen R" after an object number, restoring the position if absent
+func (l *lexer) tryRef(num int) (Ref, bool) {
+	save := l.pos
+	l.skipSpace()
+
+	start := l.pos
+	for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos = save
+		return Ref{}, false
+	}
+	gen, _ := strconv.Atoi(string(l.data[start:l.pos]))
+
+	l.skipSpace()
+	if l.pos < len(l.data) && l.data[l.pos] == 'R' && (l.pos+1 == len(l.data) || !isRegular(l.data[l.pos+1])) {
+		l.pos++
+		return Ref{Num: num, Gen: gen}, true
+	}
+
+	l.pos = save
+	return Ref{}, false
+}
+
+func (l *lexer) readLiteralString() (Object, error) {
+	l.pos++ // skip '('
+	var buf bytes.Buffer
+	depth := 1
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		switch c {
+		case '(':
+			depth++
+			buf.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(buf.Bytes()), nil
+			}
+			buf.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, errEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case '\r':
+				// Line continuation; also swallow a following LF
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf.WriteByte(byte(v))
+				} else {
+					buf.WriteByte(e)
+				}
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return nil, errEOF
+}
+
+func (l *lexer) readHexString() (Object, error) {
+	l.pos++ // skip '<'
+	var digits []byte
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			out := make([]byte, len(digits)/2)
+			for i := range out {
+				v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+				out[i] = byte(v)
+			}
+			return String(out), nil
+		}
+		if isWhitespace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+
+	return nil, errEOF
+}
+
+// enter tracks one more level of nesting, failing once the limit is reached
+func (l *lexer) enter() error {
+	if l.depth >= maxNestingDepth {
+		return errTooDeep
+	}
+	l.depth++
+	return nil
+}
+
+func (l *lexer) readArray() (Object, error) {
+	if err := l.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { l.depth-- }()
+
+	arr := Array{}
+	for {
+		obj, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := obj.(Keyword); ok && kw == "]" {
+			return arr, nil
+		}
+		arr = append(arr, obj)
+	}
+}
+
+func (l *lexer) readDict() (Object, error) {
+	if err := l.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { l.depth-- }()
+
+	dict := Dict{}
+	for {
+		key, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := key.(Keyword); ok && kw == ">>" {
+			return dict, nil
+		}
+
+		name, ok := key.(Name)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key is not a name: %v", key)
+		}
+
+		value, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := value.(Keyword); ok && kw == ">>" {
+			// Malformed dictionary with a dangling key
+			dict[name] = nil
+			return dict, nil
+		}
+		dict[name] = value
+	}
+}
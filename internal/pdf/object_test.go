@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLexerNestingLimit(t *testing.T) {
+	shallow := strings.Repeat("[", 10) + strings.Repeat("]", 10)
+	if _, err := (&lexer{data: []byte(shallow)}).next(); err != nil {
+		t.Fatalf("10 nested arrays: unexpected error %v", err)
+	}
+
+	deep := strings.Repeat("[", maxNestingDepth+1) + strings.Repeat("]", maxNestingDepth+1)
+	if _, err := (&lexer{data: []byte(deep)}).next(); !errors.Is(err, errTooDeep) {
+		t.Fatalf("%d nested arrays: got %v, want errTooDeep", maxNestingDepth+1, err)
+	}
+
+	dicts := strings.Repeat("<</A ", maxNestingDepth+1) + strings.Repeat(">>", maxNestingDepth+1)
+	if _, err := (&lexer{data: []byte(dicts)}).next(); !errors.Is(err, errTooDeep) {
+		t.Fatalf("%d nested dictionaries: got %v, want errTooDeep", maxNestingDepth+1, err)
+	}
+}
+
+func TestOpenDeeplyNestedObject(t *testing.T) {
+	// Used to overflow the goroutine stack and kill the whole process
+	var data bytes.Buffer
+	data.WriteString("%PDF-1.4\n1 0 obj\n")
+	data.Write(bytes.Repeat([]byte("["), 9<<20))
+
+	if _, err := Open(data.Bytes()); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("got %v, want ErrMalformed", err)
+	}
+}
@@ -0,0 +1,240 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// tjSpaceThreshold is the TJ displacement (in thousandths of an em) treated as a word gap
+const tjSpaceThreshold = -200
+
+// ExtractText parses a PDF document and returns the text of every page, one text line per line
+func ExtractText(data []byte) (string, error) {
+	doc, err := Open(data)
+	if err != nil {
+		return "", err
+	}
+
+	pages, err := doc.Pages()
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for i, page := range pages {
+		content, err := doc.pageContent(page)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i+1, err)
+		}
+
+		text := doc.pageText(page, content)
+		out.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.String(), nil
+}
+
+// textWriter accumulates shown text, breaking lines when the text position moves vertically
+type textWriter struct {
+	buf      strings.Builder
+	lineY    float64
+	haveLine bool
+	pending  bool // a word gap was requested since the last write
+}
+
+func (w *textWriter) newline() {
+	if w.buf.Len() > 0 && !strings.HasSuffix(w.buf.String(), "\n") {
+		w.buf.WriteByte('\n')
+	}
+	w.pending = false
+}
+
+func (w *textWriter) space() {
+	w.pending = true
+}
+
+// moveTo records a new text position, starting a new line if the baseline changed
+func (w *textWriter) moveTo(y float64, xMoved bool) {
+	if w.haveLine && math.Abs(y-w.lineY) > 1 {
+		w.newline()
+	} else if xMoved {
+		w.space()
+	}
+	w.lineY = y
+	w.haveLine = true
+}
+
+func (w *textWriter) write(text string) {
+	if text == "" {
+		return
+	}
+	if w.pending {
+		s := w.buf.String()
+		if len(s) > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			w.buf.WriteByte(' ')
+		}
+		w.pending = false
+	}
+	w.buf.WriteString(text)
+}
+
+// pageText interprets the text operators of a content stream
+func (d *Document) pageText(page Dict, content []byte) string {
+	fonts := make(map[Name]*font)
+	fontDicts := d.dict(d.dict(page["Resources"])["Font"])
+
+	lookupFont := func(name Name) *font {
+		if f, ok := fonts[name]; ok {
+			return f
+		}
+		f := d.newFont(d.dict(fontDicts[name]))
+		fonts[name] = f
+		return f
+	}
+
+	w := &textWriter{}
+	current := &font{codeLengths: []int{1}, encoding: &standardEncoding}
+	var leading, lineX, lineY float64
+
+	show := func(obj Object) {
+		switch v := obj.(type) {
+		case String:
+			w.write(current.decode(v))
+		case Array:
+			for _, item := range v {
+				switch part := item.(type) {
+				case String:
+					w.write(current.decode(part))
+				case int:
+					if part < tjSpaceThreshold {
+						w.space()
+					}
+				case float64:
+					if part < tjSpaceThreshold {
+						w.space()
+					}
+				}
+			}
+		}
+	}
+
+	nextLine := func() {
+		lineY -= leading
+		w.moveTo(lineY, false)
+		w.newline()
+	}
+
+	lex := &lexer{data: content}
+	var operands []Object
+
+	for {
+		obj, err := lex.next()
+		if err != nil {
+			break
+		}
+
+		op, ok := obj.(Keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			lineX, lineY = 0, 0
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(Name); ok {
+					current = lookupFont(name)
+				}
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				leading = number(operands[len(operands)-1])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, ty := number(operands[len(operands)-2]), number(operands[len(operands)-1])
+				if op == "TD" {
+					leading = -ty
+				}
+				lineX += tx
+				lineY += ty
+				w.moveTo(lineY, tx != 0)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				x, y := number(operands[len(operands)-2]), number(operands[len(operands)-1])
+				xMoved := x != lineX
+				lineX, lineY = x, y
+				w.moveTo(lineY, xMoved)
+			}
+		case "T*":
+			nextLine()
+		case "Tj":
+			if len(operands) >= 1 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				show(operands[len(operands)-1])
+			}
+		case "'":
+			nextLine()
+			if len(operands) >= 1 {
+				show(operands[len(operands)-1])
+			}
+		case "\"":
+			nextLine()
+			if len(operands) >= 3 {
+				show(operands[len(operands)-1])
+			}
+		case "ET":
+			w.space()
+		case "BI":
+			skipInlineImage(lex)
+		}
+
+		operands = operands[:0]
+	}
+
+	return w.buf.String()
+}
+
+// skipInlineImage advances past the binary data of an inline image (BI ... ID data EI)
+func skipInlineImage(lex *lexer) {
+	idx := bytes.Index(lex.data[lex.pos:], []byte("ID"))
+	if idx < 0 {
+		lex.pos = len(lex.data)
+		return
+	}
+	lex.pos += idx + 2
+
+	for lex.pos < len(lex.data) {
+		idx := bytes.Index(lex.data[lex.pos:], []byte("EI"))
+		if idx < 0 {
+			lex.pos = len(lex.data)
+			return
+		}
+		end := lex.pos + idx
+		lex.pos = end + 2
+		if end > 0 && isWhitespace(lex.data[end-1]) && (lex.pos >= len(lex.data) || !isRegular(lex.data[lex.pos])) {
+			return
+		}
+	}
+}
+
+func number(obj Object) float64 {
+	switch v := obj.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractTextFixtures(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"flate.pdf", "Gabarito\n1 - A\n2 - C\n"},
+		{"tj.pdf", "Prova de Historia\nGabarito\n1 B 2 D\n"},
+		{"multipage.pdf", "Pagina 1\n1 - A\nPagina 2\n2 - B\nPagina 3\n3 - E\n"},
+		{"encoding.pdf", "Questão 1 – opção “C”\nÇABãCDE\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ExtractText(data)
+			if err != nil {
+				t.Fatalf("ExtractText: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractText =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"exam-helper/internal/models"
+)
+
+const (
+	examsDirName      = "exams"
+	schemaVersionFile = "schema_version"
+)
+
+// migration upgrades the on-disk layout of a data directory by one version
+type migration struct {
+	version     int
+	description string
+	apply       func(dataDir string) error
+}
+
+// migrations are applied in order; append new entries, never edit released ones
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create exams directory",
+		apply: func(dataDir string) error {
+			return os.MkdirAll(filepath.Join(dataDir, examsDirName), 0755)
+		},
+	},
+}
+
+// FileRepository stores each exam as a JSON document inside a data directory
+type FileRepository struct {
+	dataDir string
+	mutex   sync.RWMutex
+}
+
+// NewFileRepository opens (and migrates if needed) the data directory
+func NewFileRepository(dataDir string) (*FileRepository, error) {
+	if dataDir == "" {
+		return nil, errors.New("data directory is required for file storage")
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	if err := migrate(dataDir); err != nil {
+		return nil, err
+	}
+
+	return &FileRepository{dataDir: dataDir}, nil
+}
+
+// Create stores a new exam
+func (r *FileRepository) Create(exam *models.Exam) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path, err := r.examPath(exam.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return ErrExamExists
+	}
+
+	return writeJSON(path, exam)
+}
+
+// Get returns the exam with the given ID
+func (r *FileRepository) Get(id string) (*models.Exam, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	path, err := r.examPath(id)
+	if err != nil {
+		return nil, ErrExamNotFound
+	}
+
+	return readExam(path)
+}
+
+// Update replaces a previously stored exam
+func (r *FileRepository) Update(exam *models.Exam) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path, err := r.examPath(exam.ID)
+	if err != nil {
+		return ErrExamNotFound
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrExamNotFound
+	}
+
+	return writeJSON(path, exam)
+}
+
+// Delete removes an exam
+func (r *FileRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path, err := r.examPath(id)
+	if err != nil {
+		return ErrExamNotFound
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrExamNotFound
+		}
+		return fmt.Errorf("failed to delete exam: %w", err)
+	}
+
+	return nil
+}
+
+// List returns every stored exam
+func (r *FileRepository) List() ([]*models.Exam, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(r.dataDir, examsDirName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exams directory: %w", err)
+	}
+
+	exams := make([]*models.Exam, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		// One damaged document must not hide every other exam
+		exam, err := readExam(filepath.Join(r.dataDir, examsDirName, entry.Name()))
+		if err != nil {
+			log.Printf("Skipping exam document %s: %v", entry.Name(), err)
+			continue
+		}
+		exams = append(exams, exam)
+	}
+
+	return exams, nil
+}
+
+// Find returns one page of the stored exams matching query
+func (r *FileRepository) Find(query ExamQuery) (*ExamPage, error) {
+	exams, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(exams, query)
+}
+
+// Close is a no-op; every write is flushed before returning
+func (r *FileRepository) Close() error {
+	return nil
+}
+
+// examPath maps an exam ID to its document, rejecting IDs that could escape the data directory
+func (r *FileRepository) examPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid exam ID: %q", id)
+	}
+
+	return filepath.Join(r.dataDir, examsDirName, id+".json"), nil
+}
+
+// readExam decodes a single exam document
+func readExam(path string) (*models.Exam, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrExamNotFound
+		}
+		return nil, fmt.Errorf("failed to read exam: %w", err)
+	}
+
+	var exam models.Exam
+	if err := json.Unmarshal(data, &exam); err != nil {
+		return nil, fmt.Errorf("failed to decode exam %s: %w", filepath.Base(path), err)
+	}
+
+	return &exam, nil
+}
+
+// writeJSON writes a document atomically by renaming a fully written temp file into place
+func writeJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync document: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close document: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+
+	return nil
+}
+
+// migrate brings the data directory up to the latest schema version
+func migrate(dataDir string) error {
+	current, err := readSchemaVersion(dataDir)
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("data directory schema version %d is newer than supported version %d", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := m.apply(dataDir); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+
+		if err := writeSchemaVersion(dataDir, m.version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readSchemaVersion(dataDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, schemaVersionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+func writeSchemaVersion(dataDir string, version int) error {
+	path := filepath.Join(dataDir, schemaVersionFile)
+	if err := os.WriteFile(path, []byte(strconv.Itoa(version)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write schema version: %w", err)
+	}
+
+	return nil
+}
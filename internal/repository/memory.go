@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"sync"
+
+	"exam-helper/internal/models"
+)
+
+// MemoryRepository keeps exams in process memory; everything is lost on restart
+type MemoryRepository struct {
+	exams map[string]*models.Exam
+	mutex sync.RWMutex
+}
+
+// NewMemoryRepository creates a new in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		exams: make(map[string]*models.Exam),
+	}
+}
+
+// Create stores a new exam
+func (r *MemoryRepository) Create(exam *models.Exam) error {
+	clone, err := cloneExam(exam)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.exams[exam.ID]; exists {
+		return ErrExamExists
+	}
+
+	r.exams[exam.ID] = clone
+
+	return nil
+}
+
+// Get returns a copy of the exam with the given ID
+func (r *MemoryRepository) Get(id string) (*models.Exam, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	exam, exists := r.exams[id]
+	if !exists {
+		return nil, ErrExamNotFound
+	}
+
+	return cloneExam(exam)
+}
+
+// Update replaces a previously stored exam
+func (r *MemoryRepository) Update(exam *models.Exam) error {
+	clone, err := cloneExam(exam)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.exams[exam.ID]; !exists {
+		return ErrExamNotFound
+	}
+
+	r.exams[exam.ID] = clone
+
+	return nil
+}
+
+// Delete removes an exam
+func (r *MemoryRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.exams[id]; !exists {
+		return ErrExamNotFound
+	}
+
+	delete(r.exams, id)
+
+	return nil
+}
+
+// List returns copies of every stored exam
+func (r *MemoryRepository) List() ([]*models.Exam, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	exams := make([]*models.Exam, 0, len(r.exams))
+	for _, exam := range r.exams {
+		clone, err := cloneExam(exam)
+		if err != nil {
+			return nil, err
+		}
+		exams = append(exams, clone)
+	}
+
+	return exams, nil
+}
+
+// Find returns copies of one page of the exams matching query
+func (r *MemoryRepository) Find(query ExamQuery) (*ExamPage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	exams := make([]*models.Exam, 0, len(r.exams))
+	for _, exam := range r.exams {
+		exams = append(exams, exam)
+	}
+
+	page, err := paginate(exams, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the exams on the page leave the store, so only those are copied
+	for i, exam := range page.Exams {
+		if page.Exams[i], err = cloneExam(exam); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// Close is a no-op for the in-memory repository
+func (r *MemoryRepository) Close() error {
+	return nil
+}
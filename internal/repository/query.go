@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"exam-helper/internal/models"
+)
+
+// Fields exams can be sorted by
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+)
+
+// Page size limits for Find
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidQuery is returned when a query has an unknown sort field, a bad range or a malformed cursor
+var ErrInvalidQuery = errors.New("invalid exam query")
+
+// ExamQuery filters, sorts and pages the exams returned by Find
+// Zero values disable the corresponding filter
+type ExamQuery struct {
+	Mode          models.ExamMode
+	Status        models.ExamStatus
+	Owner         string
+	OwnerTokens   []string   // Hashes of owner tokens; when non-nil only exams with one of them match
+	CreatedAfter  *time.Time // Inclusive
+	CreatedBefore *time.Time // Exclusive
+	SortBy        string     // SortCreatedAt (default) or SortUpdatedAt
+	Ascending     bool       // Newest first unless set
+	Limit         int        // DefaultPageSize when zero
+	Cursor        string     // NextCursor of the previous page
+}
+
+// ExamPage is one page of exams returned by Find
+type ExamPage struct {
+	Exams      []*models.Exam
+	NextCursor string // Empty on the last page
+}
+
+// pageCursor marks the last exam of a page; exams sort by the chosen time, then by ID
+type pageCursor struct {
+	SortBy    string    `json:"s"`
+	Ascending bool      `json:"a"`
+	Time      time.Time `json:"t"`
+	ID        string    `json:"i"`
+}
+
+// normalize fills in defaults and rejects queries that cannot be answered
+func (q *ExamQuery) normalize() error {
+	if q.SortBy == "" {
+		q.SortBy = SortCreatedAt
+	}
+	if q.SortBy != SortCreatedAt && q.SortBy != SortUpdatedAt {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, q.SortBy)
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && !q.CreatedAfter.Before(*q.CreatedBefore) {
+		return fmt.Errorf("%w: created_after must be before created_before", ErrInvalidQuery)
+	}
+
+	return nil
+}
+
+// matches reports whether an exam passes the query filters
+func (q *ExamQuery) matches(exam *models.Exam) bool {
+	switch {
+	case q.Mode != "" && exam.Mode != q.Mode:
+		return false
+	case q.Status != "" && exam.Status != q.Status:
+		return false
+	case q.Owner != "" && exam.Owner != q.Owner:
+		return false
+	case q.OwnerTokens != nil && !containsString(q.OwnerTokens, exam.OwnerTokenHash):
+		return false
+	case q.CreatedAfter != nil && exam.CreatedAt.Before(*q.CreatedAfter):
+		return false
+	case q.CreatedBefore != nil && !exam.CreatedAt.Before(*q.CreatedBefore):
+		return false
+	}
+
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortTime returns the time an exam is sorted by
+func (q *ExamQuery) sortTime(exam *models.Exam) time.Time {
+	if q.SortBy == SortUpdatedAt {
+		return exam.UpdatedAt
+	}
+
+	return exam.CreatedAt
+}
+
+// less orders two exams by sort time, breaking ties by ID so pages never overlap
+func (q *ExamQuery) less(aTime time.Time, aID string, bTime time.Time, bID string) bool {
+	if !aTime.Equal(bTime) {
+		if q.Ascending {
+			return aTime.Before(bTime)
+		}
+		return aTime.After(bTime)
+	}
+
+	return aID < bID
+}
+
+// paginate filters, sorts and cuts one page out of exams
+// The page holds the given exam pointers; callers clone them if they belong to the store
+func paginate(exams []*models.Exam, query ExamQuery) (*ExamPage, error) {
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+
+	var after *pageCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != query.SortBy || cursor.Ascending != query.Ascending {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidQuery)
+		}
+		after = cursor
+	}
+
+	filtered := make([]*models.Exam, 0, len(exams))
+	for _, exam := range exams {
+		if !query.matches(exam) {
+			continue
+		}
+		if after != nil && !query.less(after.Time, after.ID, query.sortTime(exam), exam.ID) {
+			continue
+		}
+		filtered = append(filtered, exam)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return query.less(query.sortTime(filtered[i]), filtered[i].ID, query.sortTime(filtered[j]), filtered[j].ID)
+	})
+
+	page := &ExamPage{Exams: filtered}
+	if len(filtered) > query.Limit {
+		page.Exams = filtered[:query.Limit]
+		last := page.Exams[len(page.Exams)-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			SortBy:    query.SortBy,
+			Ascending: query.Ascending,
+			Time:      query.sortTime(last),
+			ID:        last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// encodeCursor turns a cursor into an opaque URL-safe string
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(value string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	return &cursor, nil
+}
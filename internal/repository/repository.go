@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"exam-helper/internal/models"
+)
+
+// Storage drivers supported by New
+const (
+	DriverMemory = "memory"
+	DriverFile   = "file"
+)
+
+// ErrExamNotFound is returned when an exam does not exist in the repository
+var ErrExamNotFound = errors.New("exam not found")
+
+// ErrExamExists is returned when creating an exam whose ID is already stored
+var ErrExamExists = errors.New("exam already exists")
+
+// ExamRepository persists exam sessions
+type ExamRepository interface {
+	// Create stores a new exam
+	Create(exam *models.Exam) error
+	// Get returns a copy of the exam with the given ID
+	Get(id string) (*models.Exam, error)
+	// Update replaces a previously stored exam
+	Update(exam *models.Exam) error
+	// Delete removes an exam
+	Delete(id string) error
+	// List returns copies of every stored exam
+	List() ([]*models.Exam, error)
+	// Find returns copies of one page of the exams matching query
+	Find(query ExamQuery) (*ExamPage, error)
+	// Close releases any resources held by the repository
+	Close() error
+}
+
+// New creates the repository selected by driver
+func New(driver, dataDir string) (ExamRepository, error) {
+	switch driver {
+	case "", DriverMemory:
+		return NewMemoryRepository(), nil
+	case DriverFile:
+		return NewFileRepository(dataDir)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", driver)
+	}
+}
+
+// cloneExam returns a deep copy of an exam so callers never share state with the store
+func cloneExam(exam *models.Exam) (*models.Exam, error) {
+	data, err := json.Marshal(exam)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode exam: %w", err)
+	}
+
+	var clone models.Exam
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to decode exam: %w", err)
+	}
+
+	return &clone, nil
+}
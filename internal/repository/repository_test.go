@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exam-helper/internal/models"
+)
+
+var baseTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestExam(id string, mode models.ExamMode, created time.Time) *models.Exam {
+	return &models.Exam{
+		ID:        id,
+		Mode:      mode,
+		Status:    models.StatusPending,
+		Answers:   map[string]string{},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	runContract(t, func(t *testing.T) ExamRepository {
+		return NewMemoryRepository()
+	})
+}
+
+func TestFileRepository(t *testing.T) {
+	runContract(t, func(t *testing.T) ExamRepository {
+		repo, err := NewFileRepository(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewFileRepository: %v", err)
+		}
+		return repo
+	})
+}
+
+// runContract checks the behaviour every ExamRepository must share
+func runContract(t *testing.T, newRepo func(t *testing.T) ExamRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		exam := newTestExam("exam-1", models.ModeTimer, baseTime)
+		exam.Answers["1"] = "A"
+
+		if err := repo.Create(exam); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		got, err := repo.Get("exam-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.ID != "exam-1" || got.Mode != models.ModeTimer || got.Answers["1"] != "A" || !got.CreatedAt.Equal(baseTime) {
+			t.Fatalf("Get returned %+v", got)
+		}
+
+		// Neither the caller's exam nor a returned copy may alias the stored one
+		exam.Answers["1"] = "B"
+		got.Answers["1"] = "C"
+		again, err := repo.Get("exam-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if again.Answers["1"] != "A" {
+			t.Fatalf("stored answer changed to %q through a copy", again.Answers["1"])
+		}
+	})
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newTestExam("exam-1", models.ModeTimer, baseTime)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		err := repo.Create(newTestExam("exam-1", models.ModeStopwatch, baseTime))
+		if !errors.Is(err, ErrExamExists) {
+			t.Fatalf("got %v, want ErrExamExists", err)
+		}
+
+		got, _ := repo.Get("exam-1")
+		if got.Mode != models.ModeTimer {
+			t.Fatal("a rejected Create overwrote the stored exam")
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		if _, err := newRepo(t).Get("missing"); !errors.Is(err, ErrExamNotFound) {
+			t.Fatalf("got %v, want ErrExamNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		exam := newTestExam("exam-1", models.ModeTimer, baseTime)
+		if err := repo.Create(exam); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		exam.Status = models.StatusActive
+		exam.AnswersVersion = 3
+		if err := repo.Update(exam); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repo.Get("exam-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Status != models.StatusActive || got.AnswersVersion != 3 {
+			t.Fatalf("Update not stored: %+v", got)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		err := newRepo(t).Update(newTestExam("missing", models.ModeTimer, baseTime))
+		if !errors.Is(err, ErrExamNotFound) {
+			t.Fatalf("got %v, want ErrExamNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(newTestExam("exam-1", models.ModeTimer, baseTime)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		if err := repo.Delete("exam-1"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.Get("exam-1"); !errors.Is(err, ErrExamNotFound) {
+			t.Fatalf("Get after Delete: got %v, want ErrExamNotFound", err)
+		}
+		if err := repo.Delete("exam-1"); !errors.Is(err, ErrExamNotFound) {
+			t.Fatalf("second Delete: got %v, want ErrExamNotFound", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		exams, err := repo.List()
+		if err != nil || len(exams) != 0 {
+			t.Fatalf("empty List = %v, %v", exams, err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if err := repo.Create(newTestExam(fmt.Sprintf("exam-%d", i), models.ModeTimer, baseTime)); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		exams, err = repo.List()
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(exams) != 3 {
+			t.Fatalf("List returned %d exams, want 3", len(exams))
+		}
+	})
+
+	t.Run("FindFilters", func(t *testing.T) {
+		repo := newRepo(t)
+		timer := newTestExam("timer", models.ModeTimer, baseTime)
+		timer.Owner = "ana"
+		timer.OwnerTokenHash = "hash-a"
+		stopwatch := newTestExam("stopwatch", models.ModeStopwatch, baseTime.Add(time.Hour))
+		stopwatch.Status = models.StatusCompleted
+		for _, exam := range []*models.Exam{timer, stopwatch} {
+			if err := repo.Create(exam); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		after := baseTime.Add(time.Minute)
+		tests := []struct {
+			query ExamQuery
+			want  string
+		}{
+			{ExamQuery{Mode: models.ModeTimer}, "timer"},
+			{ExamQuery{Status: models.StatusCompleted}, "stopwatch"},
+			{ExamQuery{Owner: "ana"}, "timer"},
+			{ExamQuery{CreatedAfter: &after}, "stopwatch"},
+			{ExamQuery{CreatedBefore: &after}, "timer"},
+			{ExamQuery{OwnerTokens: []string{"hash-b", "hash-a"}}, "timer"},
+		}
+
+		for _, tt := range tests {
+			page, err := repo.Find(tt.query)
+			if err != nil {
+				t.Fatalf("Find(%+v): %v", tt.query, err)
+			}
+			if len(page.Exams) != 1 || page.Exams[0].ID != tt.want {
+				t.Fatalf("Find(%+v) returned %d exams, want only %s", tt.query, len(page.Exams), tt.want)
+			}
+		}
+
+		// A non-nil but empty token list matches nothing
+		page, err := repo.Find(ExamQuery{OwnerTokens: []string{}})
+		if err != nil || len(page.Exams) != 0 {
+			t.Fatalf("Find without owner tokens returned %d exams, %v", len(page.Exams), err)
+		}
+
+		if _, err := repo.Find(ExamQuery{SortBy: "name"}); !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("unknown sort field: got %v, want ErrInvalidQuery", err)
+		}
+		if _, err := repo.Find(ExamQuery{Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidQuery) {
+			t.Fatalf("malformed cursor: got %v, want ErrInvalidQuery", err)
+		}
+	})
+
+	t.Run("FindPages", func(t *testing.T) {
+		repo := newRepo(t)
+		for i := 0; i < 5; i++ {
+			exam := newTestExam(fmt.Sprintf("exam-%d", i), models.ModeTimer, baseTime.Add(time.Duration(i)*time.Minute))
+			if err := repo.Create(exam); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		var ids []string
+		query := ExamQuery{Limit: 2}
+		for {
+			page, err := repo.Find(query)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			for _, exam := range page.Exams {
+				ids = append(ids, exam.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		want := "[exam-4 exam-3 exam-2 exam-1 exam-0]"
+		if got := fmt.Sprint(ids); got != want {
+			t.Fatalf("pages returned %s, want %s", got, want)
+		}
+	})
+}
+
+func TestFileRepositoryRejectsPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	repo, err := NewFileRepository(dataDir)
+	if err != nil {
+		t.Fatalf("NewFileRepository: %v", err)
+	}
+
+	for _, id := range []string{"", "../escaped", "../../escaped", "a/b", `a\b`, ".."} {
+		if _, err := repo.examPath(id); err == nil {
+			t.Errorf("examPath(%q) should have been rejected", id)
+		}
+		if err := repo.Create(newTestExam(id, models.ModeTimer, baseTime)); err == nil {
+			t.Errorf("Create(%q) should have failed", id)
+		}
+		if _, err := repo.Get(id); !errors.Is(err, ErrExamNotFound) {
+			t.Errorf("Get(%q): got %v, want ErrExamNotFound", id, err)
+		}
+		if err := repo.Delete(id); !errors.Is(err, ErrExamNotFound) {
+			t.Errorf("Delete(%q): got %v, want ErrExamNotFound", id, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escaped.json")); !os.IsNotExist(err) {
+		t.Fatal("an exam was written outside the data directory")
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "escaped.json")); !os.IsNotExist(err) {
+		t.Fatal("an exam was written outside the exams directory")
+	}
+}
+
+func TestFileRepositoryPersists(t *testing.T) {
+	dataDir := t.TempDir()
+	repo, err := NewFileRepository(dataDir)
+	if err != nil {
+		t.Fatalf("NewFileRepository: %v", err)
+	}
+	if err := repo.Create(newTestExam("exam-1", models.ModeTimer, baseTime)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	reopened, err := NewFileRepository(dataDir)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	if _, err := reopened.Get("exam-1"); err != nil {
+		t.Fatalf("Get after reopening: %v", err)
+	}
+}
+
+func TestFileRepositorySkipsCorruptDocuments(t *testing.T) {
+	dataDir := t.TempDir()
+	repo, err := NewFileRepository(dataDir)
+	if err != nil {
+		t.Fatalf("NewFileRepository: %v", err)
+	}
+	if err := repo.Create(newTestExam("exam-1", models.ModeTimer, baseTime)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for name, data := range map[string]string{"corrupt.json": "{not json", "truncated.json": `{"id": "exam-2", "mode"`} {
+		if err := os.WriteFile(filepath.Join(dataDir, examsDirName, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exams, err := repo.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(exams) != 1 || exams[0].ID != "exam-1" {
+		t.Fatalf("List returned %d exams, want only exam-1", len(exams))
+	}
+
+	page, err := repo.Find(ExamQuery{})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(page.Exams) != 1 {
+		t.Fatalf("Find returned %d exams, want 1", len(page.Exams))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := migrate(dataDir); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	version, err := readSchemaVersion(dataDir)
+	if err != nil {
+		t.Fatalf("readSchemaVersion: %v", err)
+	}
+	if latest := migrations[len(migrations)-1].version; version != latest {
+		t.Fatalf("schema version %d, want %d", version, latest)
+	}
+	if info, err := os.Stat(filepath.Join(dataDir, examsDirName)); err != nil || !info.IsDir() {
+		t.Fatal("migration did not create the exams directory")
+	}
+
+	// Running again on an up-to-date directory is a no-op
+	if err := migrate(dataDir); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	dataDir := t.TempDir()
+	newer := migrations[len(migrations)-1].version + 1
+	if err := writeSchemaVersion(dataDir, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrate(dataDir); err == nil {
+		t.Fatal("migrate accepted a schema version newer than it knows")
+	}
+	if _, err := NewFileRepository(dataDir); err == nil {
+		t.Fatal("NewFileRepository opened a data directory with a newer schema version")
+	}
+
+	// The directory is left untouched for the newer release that wrote it
+	if version, _ := readSchemaVersion(dataDir); version != newer {
+		t.Fatalf("schema version changed to %d", version)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passage of time so exam timing can be driven deterministically
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call
+type Timer interface {
+	// Stop prevents the call from firing; it reports whether the call was still pending
+	Stop() bool
+}
+
+// SystemClock is the Clock backed by the real wall clock
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is a manually advanced Clock; timers fire synchronously inside Advance
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	mutex  sync.Mutex
+}
+
+type fakeTimer struct {
+	clock   *FakeClock
+	when    time.Time
+	f       func()
+	stopped bool
+}
+
+// NewFakeClock creates a fake clock that starts at the given time
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the fake current time
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// AfterFunc registers f to run once the clock has been advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &fakeTimer{clock: c, when: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward and runs every timer that became due, in deadline order
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var due, pending []*fakeTimer
+	for _, t := range c.timers {
+		if t.stopped {
+			continue
+		}
+		if !t.when.After(now) {
+			t.stopped = true
+			due = append(due, t)
+		} else {
+			pending = append(pending, t)
+		}
+	}
+	c.timers = pending
+	c.mutex.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].when.Before(due[j].when)
+	})
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// PendingTimers returns how many timers have not fired or been stopped
+func (c *FakeClock) PendingTimers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := 0
+	for _, t := range c.timers {
+		if !t.stopped {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	wasPending := !t.stopped
+	t.stopped = true
+
+	return wasPending
+}
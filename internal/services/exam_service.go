@@ -1,63 +1,208 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"exam-helper/internal/models"
+	"exam-helper/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+// LatePolicy decides what happens to a timer exam submitted after its deadline and grace period
+type LatePolicy string
+
+const (
+	LatePolicyReject    LatePolicy = "reject"    // Refuse the submission and expire the exam
+	LatePolicyFlag      LatePolicy = "flag"      // Grade the submitted answers and flag the result as late
+	LatePolicyAutosaved LatePolicy = "autosaved" // Ignore the submission and grade the answers stored before the deadline
+)
+
+// ParseLatePolicy validates a configured late submission policy; an empty value means reject
+func ParseLatePolicy(value string) (LatePolicy, error) {
+	switch policy := LatePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return LatePolicyReject, nil
+	case LatePolicyReject, LatePolicyFlag, LatePolicyAutosaved:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown late submission policy %q, expected %s, %s or %s", value, LatePolicyReject, LatePolicyFlag, LatePolicyAutosaved)
+}
+
+// ErrInvalidExam is returned when an exam cannot be created from the given request
+var ErrInvalidExam = errors.New("invalid exam")
+
+// ErrDeadlinePassed is returned when a late submission is rejected
+var ErrDeadlinePassed = errors.New("exam deadline has passed")
+
+// ErrStaleAnswers is returned when an autosave was based on an outdated answers version
+var ErrStaleAnswers = errors.New("answers were modified by another save")
+
+// ErrInvalidAnswer is returned when an answer is not one of the exam's options
+var ErrInvalidAnswer = errors.New("invalid answer")
+
+// ErrResultNotAvailable is returned when an exam has no stored result yet
+var ErrResultNotAvailable = errors.New("exam result is not available")
+
+// ErrAnswerKeyHidden is returned when the answer key is requested before the exam ends by someone other than its owner
+var ErrAnswerKeyHidden = errors.New("answer key is only available to the exam owner or after the exam ends")
+
+// ErrNotOwner is returned when an owner-only operation is attempted without the owner token
+var ErrNotOwner = errors.New("only the exam owner can do this")
+
+// ErrExamInProgress is returned when an active exam would have to be archived
+var ErrExamInProgress = errors.New("exam is in progress")
+
+// SubmissionRules configures how submissions near the deadline are treated
+type SubmissionRules struct {
+	GracePeriod time.Duration
+	LatePolicy  LatePolicy
+}
+
 // ExamService handles exam-related business logic
 type ExamService struct {
-	exams      map[string]*models.Exam
+	repo       repository.ExamRepository
 	mutex      sync.RWMutex
 	pdfService *PDFService
+	clock      Clock
+	scheduler  *DeadlineScheduler
+	rules      SubmissionRules
+	examPDFs   examPDFIndex
 }
 
-// NewExamService creates a new exam service instance
-func NewExamService(pdfService *PDFService) *ExamService {
-	return &ExamService{
-		exams:      make(map[string]*models.Exam),
+// NewExamService creates a new exam service instance backed by the given repository
+// All timing decisions use clock; pass SystemClock outside of tests
+func NewExamService(repo repository.ExamRepository, pdfService *PDFService, clock Clock, rules SubmissionRules) *ExamService {
+	if rules.LatePolicy == "" {
+		rules.LatePolicy = LatePolicyReject
+	}
+
+	s := &ExamService{
+		repo:       repo,
 		pdfService: pdfService,
+		clock:      clock,
+		rules:      rules,
 	}
+	s.scheduler = NewDeadlineScheduler(clock, s.expireExam)
+
+	return s
+}
+
+// RestoreTimers re-arms the deadlines of active timer exams loaded from storage
+// Exams whose deadline passed while the server was down are expired immediately
+func (s *ExamService) RestoreTimers() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exams, err := s.repo.List()
+	if err != nil {
+		return fmt.Errorf("failed to list exams: %w", err)
+	}
+
+	now := s.clock.Now()
+	for _, exam := range exams {
+		if exam.Status != models.StatusActive {
+			continue
+		}
+
+		deadline, ok := exam.Deadline()
+		if !ok {
+			continue
+		}
+
+		if !deadline.Add(s.rules.GracePeriod).After(now) {
+			if err := s.markExpired(exam, deadline); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.scheduler.Schedule(exam.ID, deadline.Add(s.rules.GracePeriod))
+	}
+
+	return nil
+}
+
+// Close stops every pending deadline timer
+func (s *ExamService) Close() {
+	s.scheduler.Stop()
 }
 
 // CreateExam creates a new exam session
-func (s *ExamService) CreateExam(req models.CreateExamRequest, examPDFPath, answerKeyPath string) (*models.Exam, error) {
+// answerKey is the already parsed and validated key of answerKeyPath
+// The returned owner token grants access to the answer key; only its hash is stored
+func (s *ExamService) CreateExam(req models.CreateExamRequest, examPDFPath, answerKeyPath string, answerKey *models.AnswerKey) (*models.Exam, string, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if answerKey == nil || len(answerKey.Questions) == 0 {
+		return nil, "", fmt.Errorf("%w: answer key is required", ErrInvalidExam)
+	}
+
 	// Validate timer mode requirements
 	if req.Mode == models.ModeTimer && req.Duration == nil {
-		return nil, errors.New("duration is required for timer mode")
+		return nil, "", fmt.Errorf("%w: duration is required for timer mode", ErrInvalidExam)
 	}
 
 	// Validate stopwatch mode requirements
 	if req.Mode == models.ModeStopwatch && req.Duration != nil {
-		return nil, errors.New("duration should not be provided for stopwatch mode")
+		return nil, "", fmt.Errorf("%w: duration should not be provided for stopwatch mode", ErrInvalidExam)
+	}
+
+	if req.Scoring.Scheme == "" {
+		req.Scoring.Scheme = models.ScoringPercentage
+	}
+
+	if _, err := NewScorer(req.Scoring); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidExam, err)
+	}
+
+	// Judgement items only make sense with a Certo/Errado key
+	if req.Scoring.Scheme == models.ScoringCespe && answerKey.OptionSet.Name != models.OptionSetCE {
+		return nil, "", fmt.Errorf("%w: %s scoring requires the %s option set", ErrInvalidExam, models.ScoringCespe, models.OptionSetCE)
+	}
+
+	ownerToken, err := newOwnerToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate owner token: %w", err)
 	}
 
+	now := s.clock.Now()
 	exam := &models.Exam{
-		ID:            uuid.New().String(),
-		Mode:          req.Mode,
-		Status:        models.StatusPending,
-		ExamPDFPath:   examPDFPath,
-		AnswerKeyPath: answerKeyPath,
-		Duration:      req.Duration,
-		Answers:       make(map[string]string),
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		ID:             uuid.New().String(),
+		Mode:           req.Mode,
+		Status:         models.StatusPending,
+		ExamPDFPath:    examPDFPath,
+		AnswerKeyPath:  answerKeyPath,
+		AnswerKey:      answerKey,
+		OptionSet:      answerKey.OptionSet,
+		Scoring:        req.Scoring,
+		Duration:       req.Duration,
+		Answers:        make(map[string]string),
+		Owner:          req.Owner,
+		OwnerTokenHash: models.HashOwnerToken(ownerToken),
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
-	s.exams[exam.ID] = exam
+	if err := s.repo.Create(exam); err != nil {
+		return nil, "", fmt.Errorf("failed to store exam: %w", err)
+	}
+	s.examPDFs.Add(exam)
 
-	return exam, nil
+	return exam, ownerToken, nil
 }
 
 // StartExam starts an exam session
@@ -65,23 +210,27 @@ func (s *ExamService) StartExam(examID string) (*models.Exam, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	exam, exists := s.exams[examID]
-	if !exists {
-		return nil, errors.New("exam not found")
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
 	}
 
 	if exam.Status != models.StatusPending {
 		return nil, fmt.Errorf("exam is already %s", exam.Status)
 	}
 
-	now := time.Now()
+	now := s.clock.Now()
 	exam.StartTime = &now
 	exam.Status = models.StatusActive
 	exam.UpdatedAt = now
 
-	// For timer mode, schedule automatic completion
-	if exam.Mode == models.ModeTimer && exam.Duration != nil {
-		go s.scheduleAutoComplete(examID, *exam.Duration)
+	if err := s.repo.Update(exam); err != nil {
+		return nil, fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	// For timer mode, schedule automatic completion once the grace period is over
+	if deadline, ok := exam.Deadline(); ok {
+		s.scheduler.Schedule(examID, deadline.Add(s.rules.GracePeriod))
 	}
 
 	return exam, nil
@@ -92,20 +241,58 @@ func (s *ExamService) SubmitAnswers(examID string, answers map[string]string) (*
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	exam, exists := s.exams[examID]
-	if !exists {
-		return nil, errors.New("exam not found")
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
 	}
 
-	if exam.Status != models.StatusActive {
+	// Once the deadline timer graded the stored answers, a late submission is still handled by the late policy
+	expiredByTimer := exam.Status == models.StatusExpired && exam.Result != nil && exam.Result.Submission == models.SubmissionExpired
+	if exam.Status != models.StatusActive && !expiredByTimer {
 		return nil, fmt.Errorf("exam is %s and cannot accept answers", exam.Status)
 	}
 
+	normalized, err := normalizeAnswers(answers, exam.Options())
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	outcome := models.SubmissionOnTime
+	var lateBy time.Duration
+
+	// The deadline timer may not have fired yet, so check the deadline explicitly
+	if deadline, ok := exam.Deadline(); ok && now.After(deadline) {
+		lateBy = now.Sub(deadline)
+
+		switch {
+		case lateBy <= s.rules.GracePeriod && !expiredByTimer:
+			outcome = models.SubmissionGracePeriod
+		case s.rules.LatePolicy == LatePolicyFlag:
+			outcome = models.SubmissionLate
+		case s.rules.LatePolicy == LatePolicyAutosaved:
+			outcome = models.SubmissionLateAutosaved
+		default:
+			if !expiredByTimer {
+				s.scheduler.Cancel(examID)
+				if err := s.markExpired(exam, deadline); err != nil {
+					return nil, err
+				}
+			}
+			return nil, fmt.Errorf("%w: submitted %s late", ErrDeadlinePassed, lateBy.Round(time.Second))
+		}
+	}
+
 	// Update exam with answers
-	exam.Answers = answers
-	now := time.Now()
-	exam.EndTime = &now
-	exam.Status = models.StatusCompleted
+	if outcome == models.SubmissionLateAutosaved {
+		deadline, _ := exam.Deadline()
+		exam.EndTime = &deadline
+		exam.Status = models.StatusExpired
+	} else {
+		exam.Answers = normalized
+		exam.EndTime = &now
+		exam.Status = models.StatusCompleted
+	}
 	exam.UpdatedAt = now
 
 	// Grade the exam
@@ -114,30 +301,391 @@ func (s *ExamService) SubmitAnswers(examID string, answers map[string]string) (*
 		return nil, fmt.Errorf("failed to grade exam: %w", err)
 	}
 
+	result.SubmittedAt = now
+	result.Submission = outcome
+	result.LateBy = lateBy
+	exam.Result = result
+
+	if err := s.repo.Update(exam); err != nil {
+		return nil, fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	s.scheduler.Cancel(examID)
+
 	return result, nil
 }
 
+// SaveAnswers merges partial answers into an active exam
+// version must match the stored answers version, so a stale tab cannot overwrite newer answers
+func (s *ExamService) SaveAnswers(examID string, answers map[string]string, version int) (*models.Exam, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	if exam.Status != models.StatusActive {
+		return nil, fmt.Errorf("exam is %s and cannot accept answers", exam.Status)
+	}
+
+	now := s.clock.Now()
+	if deadline, ok := exam.Deadline(); ok && now.After(deadline.Add(s.rules.GracePeriod)) {
+		return nil, ErrDeadlinePassed
+	}
+
+	if version != exam.AnswersVersion {
+		return nil, fmt.Errorf("%w: current version is %d", ErrStaleAnswers, exam.AnswersVersion)
+	}
+
+	if exam.Answers == nil {
+		exam.Answers = make(map[string]string)
+	}
+
+	for questionNum, answer := range answers {
+		questionNum = parseQuestionNumber(questionNum)
+		if strings.TrimSpace(answer) == "" {
+			delete(exam.Answers, questionNum)
+			continue
+		}
+
+		option, ok := exam.Options().Normalize(answer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q for question %s. Expected %s", ErrInvalidAnswer, answer, questionNum, exam.Options())
+		}
+		exam.Answers[questionNum] = option
+	}
+
+	exam.AnswersVersion++
+	exam.UpdatedAt = now
+
+	if err := s.repo.Update(exam); err != nil {
+		return nil, fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	return exam, nil
+}
+
+// ReviseAnswerKey replaces the answer key of an exam with a revised one; only the owner may do so
+// Exams that have ended, archived ones included, are re-graded; the previous result is kept in the exam's result history
+func (s *ExamService) ReviseAnswerKey(examID, ownerToken, answerKeyPath string, answerKey *models.AnswerKey) (*models.Exam, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exam.IsOwner(ownerToken) {
+		return nil, ErrNotOwner
+	}
+
+	if answerKey == nil || len(answerKey.Questions) == 0 {
+		return nil, fmt.Errorf("%w: answer key is required", ErrInvalidExam)
+	}
+
+	// Stored answers and scoring rules depend on the option set, so a revision cannot change it
+	if answerKey.OptionSet.String() != exam.Options().String() {
+		return nil, fmt.Errorf("%w: revised key uses %s but the exam uses %s", ErrInvalidExam, answerKey.OptionSet, exam.Options())
+	}
+
+	exam.AnswerKeyPath = answerKeyPath
+	exam.AnswerKey = answerKey
+	exam.KeyRevision++
+	exam.UpdatedAt = s.clock.Now()
+
+	if exam.HasEnded() {
+		result, err := s.gradeExam(exam)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-grade exam: %w", err)
+		}
+
+		// Re-grading changes the score, not when or how the answers were submitted
+		if previous := exam.Result; previous != nil {
+			result.SubmittedAt = previous.SubmittedAt
+			result.Submission = previous.Submission
+			result.LateBy = previous.LateBy
+			exam.ResultHistory = append(exam.ResultHistory, previous)
+		} else if exam.EndTime != nil {
+			result.SubmittedAt = *exam.EndTime
+			result.Submission = models.SubmissionExpired
+		}
+
+		exam.Result = result
+	}
+
+	if err := s.repo.Update(exam); err != nil {
+		return nil, fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	return exam, nil
+}
+
+// ArchiveExam moves an exam that is not in progress to the archived state; only the owner may do so
+// Archived exams keep their result and are purged by the janitor after the retention period
+func (s *ExamService) ArchiveExam(examID, ownerToken string) (*models.Exam, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exam.IsOwner(ownerToken) {
+		return nil, ErrNotOwner
+	}
+
+	switch exam.Status {
+	case models.StatusArchived:
+		return exam, nil
+	case models.StatusActive:
+		return nil, fmt.Errorf("%w: submit it or wait for it to expire before archiving", ErrExamInProgress)
+	}
+
+	now := s.clock.Now()
+	exam.Status = models.StatusArchived
+	exam.ArchivedAt = &now
+	exam.UpdatedAt = now
+
+	if err := s.repo.Update(exam); err != nil {
+		return nil, fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	return exam, nil
+}
+
+// DeleteExam removes an exam and its uploaded files; only the owner may do so
+// Active exams are deleted too, and their deadline timer is cancelled
+func (s *ExamService) DeleteExam(examID, ownerToken string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return err
+	}
+
+	if !exam.IsOwner(ownerToken) {
+		return ErrNotOwner
+	}
+
+	return s.deleteExam(exam)
+}
+
+// PurgeArchived deletes exams archived before cutoff, with their files, and returns how many were removed
+func (s *ExamService) PurgeArchived(cutoff time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	exams, err := s.repo.List()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list exams: %w", err)
+	}
+
+	purged := 0
+	for _, exam := range exams {
+		if exam.Status != models.StatusArchived || exam.ArchivedAt == nil || !exam.ArchivedAt.Before(cutoff) {
+			continue
+		}
+
+		if err := s.deleteExam(exam); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// UploadedFiles returns the absolute paths of every file referenced by a stored exam
+func (s *ExamService) UploadedFiles() (map[string]bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exams, err := s.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exams: %w", err)
+	}
+
+	files := make(map[string]bool, 2*len(exams))
+	for _, exam := range exams {
+		for _, path := range []string{exam.ExamPDFPath, exam.AnswerKeyPath} {
+			if path == "" {
+				continue
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				files[abs] = true
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// IsExamPDF reports whether path is the exam PDF of a stored exam
+func (s *ExamService) IsExamPDF(path string) (bool, error) {
+	known, err := s.examPDFs.Contains(path, s.repo.List)
+	if err != nil {
+		return false, fmt.Errorf("failed to list exams: %w", err)
+	}
+
+	return known, nil
+}
+
+// deleteExam removes a stored exam and then its files
+// Files are removed last, so a failed delete never leaves an exam without its files
+// Callers must hold the mutex
+func (s *ExamService) deleteExam(exam *models.Exam) error {
+	s.scheduler.Cancel(exam.ID)
+
+	if err := s.repo.Delete(exam.ID); err != nil {
+		return fmt.Errorf("failed to delete exam: %w", err)
+	}
+	s.examPDFs.Remove(exam)
+
+	for _, path := range []string{exam.ExamPDFPath, exam.AnswerKeyPath} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove file %s of exam %s: %v", path, exam.ID, err)
+		}
+	}
+
+	return nil
+}
+
 // GetExam retrieves an exam by ID
 func (s *ExamService) GetExam(examID string) (*models.Exam, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	exam, exists := s.exams[examID]
-	if !exists {
-		return nil, errors.New("exam not found")
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
 	}
 
 	return exam, nil
 }
 
+// ListExams returns one page of the exams matching query, restricted to those owned by one of ownerTokens
+// Without tokens nothing is listed, so exam IDs cannot be enumerated by strangers
+func (s *ExamService) ListExams(query repository.ExamQuery, ownerTokens []string) (*repository.ExamPage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	query.OwnerTokens = make([]string, 0, len(ownerTokens))
+	for _, token := range ownerTokens {
+		if token != "" {
+			query.OwnerTokens = append(query.OwnerTokens, models.HashOwnerToken(token))
+		}
+	}
+
+	return s.repo.Find(query)
+}
+
+// GetExamMetadata returns what the answer form needs to render an exam, with weights as the exam grades them
+func (s *ExamService) GetExamMetadata(examID string) (*AnswerKeyMetadata, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	answerKey, err := s.answerKey(exam)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.pdfService.GetAnswerKeyMetadata(answerKey, exam.Scoring), nil
+}
+
+// RevealAnswerKey returns the answer key to its owner, or to anyone once the exam has ended
+func (s *ExamService) RevealAnswerKey(examID, ownerToken string) (*models.AnswerKey, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exam.CanViewAnswerKey(ownerToken) {
+		return nil, ErrAnswerKeyHidden
+	}
+
+	return s.answerKey(exam)
+}
+
+// AnswerKeyFile returns the path of the uploaded answer key file, with the same access rules as RevealAnswerKey
+func (s *ExamService) AnswerKeyFile(examID, ownerToken string) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return "", err
+	}
+
+	if !exam.CanViewAnswerKey(ownerToken) {
+		return "", ErrAnswerKeyHidden
+	}
+
+	return exam.AnswerKeyPath, nil
+}
+
+// GetResult returns the stored result of a completed or expired exam
+func (s *ExamService) GetResult(examID string) (*models.ExamResult, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exam.HasEnded() {
+		return nil, fmt.Errorf("%w: exam is %s", ErrResultNotAvailable, exam.Status)
+	}
+
+	if exam.Result == nil {
+		return nil, fmt.Errorf("%w: exam was not graded", ErrResultNotAvailable)
+	}
+
+	return exam.Result, nil
+}
+
+// GetResultHistory returns the results replaced by answer key revisions, oldest first
+func (s *ExamService) GetResultHistory(examID string) ([]*models.ExamResult, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := exam.ResultHistory
+	if history == nil {
+		history = make([]*models.ExamResult, 0)
+	}
+
+	return history, nil
+}
+
 // GetExamStatus returns the current status and time information for an exam
 func (s *ExamService) GetExamStatus(examID string) (map[string]interface{}, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	exam, exists := s.exams[examID]
-	if !exists {
-		return nil, errors.New("exam not found")
+	exam, err := s.repo.Get(examID)
+	if err != nil {
+		return nil, err
 	}
 
 	status := map[string]interface{}{
@@ -148,10 +696,11 @@ func (s *ExamService) GetExamStatus(examID string) (map[string]interface{}, erro
 
 	if exam.StartTime != nil {
 		status["start_time"] = exam.StartTime
-		status["elapsed_time"] = time.Since(*exam.StartTime)
+		elapsed := s.clock.Now().Sub(*exam.StartTime)
+		status["elapsed_time"] = elapsed
 
 		if exam.Mode == models.ModeTimer && exam.Duration != nil {
-			remaining := *exam.Duration - time.Since(*exam.StartTime)
+			remaining := *exam.Duration - elapsed
 			if remaining < 0 {
 				remaining = 0
 			}
@@ -169,31 +718,65 @@ func (s *ExamService) GetExamStatus(examID string) (map[string]interface{}, erro
 	return status, nil
 }
 
-// scheduleAutoComplete automatically completes an exam after the specified duration
-func (s *ExamService) scheduleAutoComplete(examID string, duration time.Duration) {
-	time.Sleep(duration)
-
+// expireExam automatically completes an exam once its deadline fires
+func (s *ExamService) expireExam(examID string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	exam, exists := s.exams[examID]
-	if !exists || exam.Status != models.StatusActive {
+	exam, err := s.repo.Get(examID)
+	if err != nil || exam.Status != models.StatusActive {
 		return
 	}
 
-	// Auto-complete the exam
-	now := time.Now()
-	exam.EndTime = &now
+	// The exam ended at its deadline; the grace period only delays when the timer fires
+	endTime, ok := exam.Deadline()
+	if !ok {
+		endTime = s.clock.Now()
+	}
+
+	if err := s.markExpired(exam, endTime); err != nil {
+		log.Printf("Failed to expire exam %s: %v", examID, err)
+	}
+}
+
+// markExpired flips an active exam to expired, grades its autosaved answers and stores it
+// Callers must hold the mutex
+func (s *ExamService) markExpired(exam *models.Exam, endTime time.Time) error {
+	exam.EndTime = &endTime
 	exam.Status = models.StatusExpired
-	exam.UpdatedAt = now
+	exam.UpdatedAt = s.clock.Now()
+
+	// A grading failure must not keep the exam active; the result can be produced later
+	if result, err := s.gradeExam(exam); err != nil {
+		log.Printf("Failed to grade expired exam %s: %v", exam.ID, err)
+	} else {
+		result.SubmittedAt = endTime
+		result.Submission = models.SubmissionExpired
+		exam.Result = result
+	}
+
+	if err := s.repo.Update(exam); err != nil {
+		return fmt.Errorf("failed to store exam: %w", err)
+	}
+
+	return nil
 }
 
 // gradeExam compares user answers with the answer key and returns results
 func (s *ExamService) gradeExam(exam *models.Exam) (*models.ExamResult, error) {
-	// Parse answer key from PDF
-	answerKey, err := s.pdfService.ParseAnswerKey(exam.AnswerKeyPath)
+	answerKey, err := s.answerKey(exam)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse answer key: %w", err)
+		return nil, err
+	}
+
+	scoring := exam.Scoring
+	if scoring.Scheme == "" {
+		scoring.Scheme = models.ScoringPercentage
+	}
+
+	scorer, err := NewScorer(scoring)
+	if err != nil {
+		return nil, err
 	}
 
 	var timeTaken time.Duration
@@ -202,41 +785,177 @@ func (s *ExamService) gradeExam(exam *models.Exam) (*models.ExamResult, error) {
 	}
 
 	result := &models.ExamResult{
-		ExamID:         exam.ID,
-		TotalQuestions: len(answerKey),
-		TimeTaken:      timeTaken,
-		Answers:        exam.Answers,
-		CorrectKey:     answerKey,
-		Details:        make([]models.QuestionResult, 0),
+		ExamID:            exam.ID,
+		TotalQuestions:    len(answerKey.Questions),
+		TimeTaken:         timeTaken,
+		Answers:           exam.Answers,
+		CorrectKey:        answerKey.Map(),
+		Details:           make([]models.QuestionResult, 0),
+		Scoring:           scoring,
+		GradedAt:          s.clock.Now(),
+		AnswerKeyChecksum: answerKey.Checksum,
+		AnswerKeyRevision: exam.KeyRevision,
 	}
 
 	// Compare answers
-	for questionNum, correctAnswer := range answerKey {
+	for _, question := range answerKey.Questions {
+		questionNum := strconv.Itoa(question.Number)
 		userAnswer := exam.Answers[questionNum]
-		isCorrect := strings.EqualFold(strings.TrimSpace(userAnswer), strings.TrimSpace(correctAnswer))
+		normalized, _ := exam.Options().Normalize(userAnswer)
+		isCorrect := question.Annulled || question.Accepts(normalized)
 
-		if isCorrect {
+		if question.Annulled {
+			result.AnnulledQuestions++
+		}
+
+		switch {
+		case isCorrect:
 			result.CorrectAnswers++
-		} else {
+		case normalized == "":
+			result.BlankAnswers++
+		default:
 			result.WrongAnswers++
 		}
 
 		result.Details = append(result.Details, models.QuestionResult{
-			QuestionNumber: questionNum,
-			UserAnswer:     userAnswer,
-			CorrectAnswer:  correctAnswer,
-			IsCorrect:      isCorrect,
+			QuestionNumber:  questionNum,
+			UserAnswer:      userAnswer,
+			CorrectAnswer:   question.AnswerText(),
+			AcceptedAnswers: question.AcceptedAnswers(),
+			IsCorrect:       isCorrect,
+			IsBlank:         normalized == "",
+			IsAnnulled:      question.Annulled,
+			Weight:          questionWeight(scoring, question),
+			Section:         question.Section,
 		})
 	}
 
-	// Calculate score percentage
-	if result.TotalQuestions > 0 {
-		result.Score = float64(result.CorrectAnswers) / float64(result.TotalQuestions) * 100
+	// Keys stored by older versions may not be ordered; results must be stable for diffing and export
+	sortDetails(result.Details)
+
+	result.WeightedScore, result.WeightedTotal, result.Sections = weightedTotals(result.Details, answerKey)
+
+	// Calculate net points and score percentage; penalties may make the score negative
+	result.NetScore, result.MaxScore = scorer.Score(result.Details)
+	if result.MaxScore > 0 {
+		result.Score = result.NetScore / result.MaxScore * 100
 	}
 
 	return result, nil
 }
 
+// answerKey returns the key stored with the exam
+// Exams stored before keys were parsed at creation fall back to parsing the uploaded file
+func (s *ExamService) answerKey(exam *models.Exam) (*models.AnswerKey, error) {
+	if exam.AnswerKey != nil {
+		return exam.AnswerKey, nil
+	}
+
+	answerKey, _, err := s.pdfService.ParseAnswerKey(exam.AnswerKeyPath, exam.Options())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse answer key: %w", err)
+	}
+
+	return answerKey, nil
+}
+
+// questionWeight returns the weight of a question
+// Weights given with the scoring scheme override the ones declared in the answer key
+func questionWeight(scoring models.ScoringConfig, question models.AnswerKeyQuestion) float64 {
+	if weight, ok := scoring.Weights[strconv.Itoa(question.Number)]; ok {
+		return weight
+	}
+
+	return question.EffectiveWeight()
+}
+
+// weightedTotals sums the weights of correct answers overall and per section, in answer key order
+func weightedTotals(details []models.QuestionResult, answerKey *models.AnswerKey) (float64, float64, []models.SectionResult) {
+	var earned, total float64
+	sections := make([]models.SectionResult, 0, len(answerKey.Sections))
+	index := make(map[string]int)
+
+	for _, section := range answerKey.Sections {
+		if _, exists := index[section.Name]; exists {
+			continue
+		}
+		index[section.Name] = len(sections)
+		sections = append(sections, models.SectionResult{Name: section.Name})
+	}
+
+	for _, d := range details {
+		total += d.Weight
+		if d.IsCorrect {
+			earned += d.Weight
+		}
+
+		i, exists := index[d.Section]
+		if !exists {
+			// Questions listed before the first header
+			if len(answerKey.Sections) == 0 {
+				continue
+			}
+			index[d.Section] = len(sections)
+			i = len(sections)
+			sections = append(sections, models.SectionResult{Name: d.Section})
+		}
+
+		section := &sections[i]
+		section.TotalQuestions++
+		section.WeightedTotal += d.Weight
+		switch {
+		case d.IsCorrect:
+			section.CorrectAnswers++
+			section.WeightedScore += d.Weight
+		case d.IsBlank:
+			section.BlankAnswers++
+		default:
+			section.WrongAnswers++
+		}
+	}
+
+	return earned, total, sections
+}
+
+// sortDetails orders question results by numeric question number
+// Details are built from the key's integer question numbers, so every number parses
+func sortDetails(details []models.QuestionResult) {
+	sort.SliceStable(details, func(i, j int) bool {
+		a, _ := strconv.Atoi(details[i].QuestionNumber)
+		b, _ := strconv.Atoi(details[j].QuestionNumber)
+		return a < b
+	})
+}
+
+// normalizeAnswers cleans submitted answers, dropping blanks and rejecting anything outside the option set
+func normalizeAnswers(answers map[string]string, optionSet models.OptionSet) (map[string]string, error) {
+	normalized := make(map[string]string, len(answers))
+	for questionNum, answer := range answers {
+		questionNum = parseQuestionNumber(questionNum)
+		if strings.TrimSpace(answer) == "" {
+			continue
+		}
+
+		option, ok := optionSet.Normalize(answer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q for question %s. Expected %s", ErrInvalidAnswer, answer, questionNum, optionSet)
+		}
+		normalized[questionNum] = option
+	}
+
+	return normalized, nil
+}
+
+// newOwnerToken returns a random token that identifies the creator of an exam
+func newOwnerToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
 // parseQuestionNumber extracts question number from various formats
 func parseQuestionNumber(text string) string {
 	// Remove common prefixes and clean up
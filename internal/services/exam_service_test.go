@@ -0,0 +1,667 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"exam-helper/internal/models"
+	"exam-helper/internal/repository"
+)
+
+var testStart = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+const (
+	testDuration = 30 * time.Minute
+	testGrace    = 10 * time.Second
+)
+
+// testAnswerKey is a three-question A–E key: 1-A, 2-B, 3-C
+func testAnswerKey() *models.AnswerKey {
+	return &models.AnswerKey{
+		Questions: []models.AnswerKeyQuestion{
+			{Number: 1, Answer: "A", Weight: 1},
+			{Number: 2, Answer: "B", Weight: 1},
+			{Number: 3, Answer: "C", Weight: 1},
+		},
+		OptionSet: models.DefaultOptionSet(),
+		Checksum:  "test",
+	}
+}
+
+func newTestService(t *testing.T, rules SubmissionRules) (*ExamService, *FakeClock) {
+	t.Helper()
+	clock := NewFakeClock(testStart)
+	service := NewExamService(repository.NewMemoryRepository(), NewPDFService(), clock, rules)
+	t.Cleanup(service.Close)
+	return service, clock
+}
+
+// startTimerExam creates and starts a timer exam lasting testDuration
+func startTimerExam(t *testing.T, service *ExamService) *models.Exam {
+	t.Helper()
+	duration := testDuration
+	exam, _, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeTimer, Duration: &duration}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+
+	exam, err = service.StartExam(exam.ID)
+	if err != nil {
+		t.Fatalf("StartExam: %v", err)
+	}
+	return exam
+}
+
+func getExam(t *testing.T, service *ExamService, id string) *models.Exam {
+	t.Helper()
+	exam, err := service.GetExam(id)
+	if err != nil {
+		t.Fatalf("GetExam: %v", err)
+	}
+	return exam
+}
+
+func TestTimerExpiresExam(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	if _, err := service.SaveAnswers(exam.ID, map[string]string{"1": "A", "2": "E"}, 0); err != nil {
+		t.Fatalf("SaveAnswers: %v", err)
+	}
+
+	// The deadline alone does not expire the exam; the grace period has to pass too
+	clock.Advance(testDuration)
+	if got := getExam(t, service, exam.ID); got.Status != models.StatusActive {
+		t.Fatalf("status at the deadline = %s, want active", got.Status)
+	}
+
+	clock.Advance(testGrace)
+	got := getExam(t, service, exam.ID)
+	if got.Status != models.StatusExpired {
+		t.Fatalf("status after the grace period = %s, want expired", got.Status)
+	}
+
+	// The exam ended at its deadline, not when the timer fired
+	deadline := testStart.Add(testDuration)
+	if got.EndTime == nil || !got.EndTime.Equal(deadline) {
+		t.Fatalf("EndTime = %v, want %v", got.EndTime, deadline)
+	}
+
+	result, err := service.GetResult(exam.ID)
+	if err != nil {
+		t.Fatalf("GetResult: %v", err)
+	}
+	if result.Submission != models.SubmissionExpired || result.TimeTaken != testDuration {
+		t.Fatalf("result submission %s after %s, want expired after %s", result.Submission, result.TimeTaken, testDuration)
+	}
+	if result.CorrectAnswers != 1 || result.WrongAnswers != 1 || result.BlankAnswers != 1 {
+		t.Fatalf("autosaved answers graded as %d/%d/%d correct/wrong/blank, want 1/1/1",
+			result.CorrectAnswers, result.WrongAnswers, result.BlankAnswers)
+	}
+}
+
+func TestRemainingTime(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	tests := []struct {
+		advance   time.Duration
+		elapsed   time.Duration
+		remaining time.Duration
+	}{
+		{0, 0, testDuration},
+		{10 * time.Minute, 10 * time.Minute, 20 * time.Minute},
+		{20*time.Minute - time.Second, testDuration - time.Second, time.Second},
+		{time.Second, testDuration, 0},
+		{5 * time.Second, testDuration + 5*time.Second, 0}, // Within the grace period, never negative
+	}
+
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+
+		status, err := service.GetExamStatus(exam.ID)
+		if err != nil {
+			t.Fatalf("GetExamStatus: %v", err)
+		}
+		if status["elapsed_time"] != tt.elapsed || status["remaining_time"] != tt.remaining {
+			t.Fatalf("after %s: elapsed %v, remaining %v; want %s, %s",
+				tt.elapsed, status["elapsed_time"], status["remaining_time"], tt.elapsed, tt.remaining)
+		}
+	}
+}
+
+func TestSubmitBeforeDeadline(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	clock.Advance(12 * time.Minute)
+	result, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A", "2": "B", "3": "C"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if result.Submission != models.SubmissionOnTime || result.TimeTaken != 12*time.Minute || result.Score != 100 {
+		t.Fatalf("result = %s after %s scoring %v, want on_time after 12m scoring 100", result.Submission, result.TimeTaken, result.Score)
+	}
+
+	// Submitting disarms the deadline, so the exam is never expired afterwards
+	if n := clock.PendingTimers(); n != 0 {
+		t.Fatalf("%d timers still pending after submission", n)
+	}
+	clock.Advance(time.Hour)
+	if got := getExam(t, service, exam.ID); got.Status != models.StatusCompleted {
+		t.Fatalf("status = %s, want completed", got.Status)
+	}
+}
+
+func TestSubmitWithinGracePeriod(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	clock.Advance(testDuration + testGrace/2)
+	result, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if result.Submission != models.SubmissionGracePeriod || result.LateBy != testGrace/2 {
+		t.Fatalf("result = %s late by %s, want grace_period late by %s", result.Submission, result.LateBy, testGrace/2)
+	}
+
+	// The timer that would have fired at the end of the grace period must not expire the completed exam
+	clock.Advance(testGrace)
+	if got := getExam(t, service, exam.ID); got.Status != models.StatusCompleted || got.Result.Submission != models.SubmissionGracePeriod {
+		t.Fatalf("exam became %s/%s after the grace period", got.Status, got.Result.Submission)
+	}
+}
+
+func TestSubmitAfterTimerFired(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	clock.Advance(testDuration + testGrace)
+	if _, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"}); !errors.Is(err, ErrDeadlinePassed) {
+		t.Fatalf("got %v, want ErrDeadlinePassed", err)
+	}
+
+	if got := getExam(t, service, exam.ID); got.Result.Submission != models.SubmissionExpired {
+		t.Fatalf("rejected submission changed the result to %s", got.Result.Submission)
+	}
+}
+
+func TestSubmitAfterDeadlineBeforeTimerFired(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	// A late submission can arrive before the timer goroutine runs; the deadline is checked explicitly
+	service.scheduler.Cancel(exam.ID)
+	clock.Advance(testDuration + time.Minute)
+
+	_, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"})
+	if !errors.Is(err, ErrDeadlinePassed) {
+		t.Fatalf("got %v, want ErrDeadlinePassed", err)
+	}
+
+	got := getExam(t, service, exam.ID)
+	deadline := testStart.Add(testDuration)
+	if got.Status != models.StatusExpired || got.EndTime == nil || !got.EndTime.Equal(deadline) {
+		t.Fatalf("exam is %s ending at %v, want expired at %v", got.Status, got.EndTime, deadline)
+	}
+}
+
+func TestSubmitRacesDeadlineTimer(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+		exam := startTimerExam(t, service)
+		clock.Advance(testDuration + testGrace - time.Millisecond)
+
+		var wg sync.WaitGroup
+		var submitErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, submitErr = service.SubmitAnswers(exam.ID, map[string]string{"1": "A"})
+		}()
+		go func() {
+			defer wg.Done()
+			clock.Advance(time.Millisecond)
+		}()
+		wg.Wait()
+
+		// Whichever wins, the exam ends exactly once and its stored result matches
+		got := getExam(t, service, exam.ID)
+		switch {
+		case submitErr == nil:
+			if got.Status != models.StatusCompleted || got.Result.Submission != models.SubmissionGracePeriod {
+				t.Fatalf("submission won but exam is %s/%s", got.Status, got.Result.Submission)
+			}
+		default:
+			if got.Status != models.StatusExpired || got.Result.Submission != models.SubmissionExpired {
+				t.Fatalf("timer won but exam is %s/%s", got.Status, got.Result.Submission)
+			}
+		}
+	}
+}
+
+func TestRestoreTimers(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	clock := NewFakeClock(testStart)
+	rules := SubmissionRules{GracePeriod: testGrace}
+
+	first := NewExamService(repo, NewPDFService(), clock, rules)
+	expired := startTimerExam(t, first)
+	clock.Advance(20 * time.Minute)
+	running := startTimerExam(t, first)
+	first.Close()
+
+	// The server was down past the first exam's deadline
+	clock.Advance(15 * time.Minute)
+	restarted := NewExamService(repo, NewPDFService(), clock, rules)
+	t.Cleanup(restarted.Close)
+	if err := restarted.RestoreTimers(); err != nil {
+		t.Fatalf("RestoreTimers: %v", err)
+	}
+
+	got := getExam(t, restarted, expired.ID)
+	if got.Status != models.StatusExpired || !got.EndTime.Equal(testStart.Add(testDuration)) {
+		t.Fatalf("overdue exam is %s ending at %v", got.Status, got.EndTime)
+	}
+	if got := getExam(t, restarted, running.ID); got.Status != models.StatusActive {
+		t.Fatalf("running exam is %s, want active", got.Status)
+	}
+
+	clock.Advance(testDuration)
+	if got := getExam(t, restarted, running.ID); got.Status != models.StatusExpired {
+		t.Fatalf("restored timer did not fire; exam is %s", got.Status)
+	}
+}
+
+func TestSaveAnswersRejectsStaleVersion(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	exam := startTimerExam(t, service)
+
+	saved, err := service.SaveAnswers(exam.ID, map[string]string{"1": "A"}, 0)
+	if err != nil {
+		t.Fatalf("SaveAnswers: %v", err)
+	}
+	if saved.AnswersVersion != 1 {
+		t.Fatalf("version = %d, want 1", saved.AnswersVersion)
+	}
+
+	// A second tab still holding version 0 must not overwrite the newer answers
+	if _, err := service.SaveAnswers(exam.ID, map[string]string{"1": "B"}, 0); !errors.Is(err, ErrStaleAnswers) {
+		t.Fatalf("got %v, want ErrStaleAnswers", err)
+	}
+	if got := getExam(t, service, exam.ID); got.Answers["1"] != "A" || got.AnswersVersion != 1 {
+		t.Fatalf("stale save changed the exam to %v at version %d", got.Answers, got.AnswersVersion)
+	}
+
+	saved, err = service.SaveAnswers(exam.ID, map[string]string{"1": "", "2": "b"}, 1)
+	if err != nil {
+		t.Fatalf("SaveAnswers with the current version: %v", err)
+	}
+	if saved.AnswersVersion != 2 || len(saved.Answers) != 1 || saved.Answers["2"] != "B" {
+		t.Fatalf("answers = %v at version %d, want only 2=B at version 2", saved.Answers, saved.AnswersVersion)
+	}
+}
+
+func TestDeleteAndArchiveRequireOwner(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{})
+	exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+
+	for _, wrong := range []string{"", "not-the-token"} {
+		if _, err := service.ArchiveExam(exam.ID, wrong); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("ArchiveExam with %q: got %v, want ErrNotOwner", wrong, err)
+		}
+		if err := service.DeleteExam(exam.ID, wrong); !errors.Is(err, ErrNotOwner) {
+			t.Errorf("DeleteExam with %q: got %v, want ErrNotOwner", wrong, err)
+		}
+	}
+	if got := getExam(t, service, exam.ID); got.Status != models.StatusPending {
+		t.Fatalf("exam is %s after refused owner operations", got.Status)
+	}
+
+	if _, err := service.StartExam(exam.ID); err != nil {
+		t.Fatalf("StartExam: %v", err)
+	}
+	if _, err := service.ArchiveExam(exam.ID, token); !errors.Is(err, ErrExamInProgress) {
+		t.Fatalf("archiving an active exam: got %v, want ErrExamInProgress", err)
+	}
+
+	if _, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"}); err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	archived, err := service.ArchiveExam(exam.ID, token)
+	if err != nil {
+		t.Fatalf("ArchiveExam: %v", err)
+	}
+	if archived.Status != models.StatusArchived || archived.ArchivedAt == nil || !archived.ArchivedAt.Equal(testStart) {
+		t.Fatalf("archived exam is %s at %v", archived.Status, archived.ArchivedAt)
+	}
+
+	if err := service.DeleteExam(exam.ID, token); err != nil {
+		t.Fatalf("DeleteExam: %v", err)
+	}
+	if _, err := service.GetExam(exam.ID); !errors.Is(err, repository.ErrExamNotFound) {
+		t.Fatalf("GetExam after delete: got %v, want ErrExamNotFound", err)
+	}
+}
+
+func TestReviseAnswerKey(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace})
+	duration := testDuration
+	exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeTimer, Duration: &duration}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+	if _, err := service.StartExam(exam.ID); err != nil {
+		t.Fatalf("StartExam: %v", err)
+	}
+
+	clock.Advance(testDuration + 5*time.Second)
+	original, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A", "2": "C", "3": "C"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if original.CorrectAnswers != 2 {
+		t.Fatalf("original result has %d correct, want 2", original.CorrectAnswers)
+	}
+
+	// The revision re-grades the stored answers without changing when or how they were submitted
+	clock.Advance(time.Hour)
+	revised := testAnswerKey()
+	revised.Questions[1].Answer = "C"
+	got, err := service.ReviseAnswerKey(exam.ID, token, "key-v2.txt", revised)
+	if err != nil {
+		t.Fatalf("ReviseAnswerKey: %v", err)
+	}
+	if got.KeyRevision != exam.KeyRevision+1 || got.AnswerKeyPath != "key-v2.txt" {
+		t.Fatalf("exam has key revision %d at %s, want %d at key-v2.txt", got.KeyRevision, got.AnswerKeyPath, exam.KeyRevision+1)
+	}
+	if got.Result.CorrectAnswers != 3 {
+		t.Fatalf("re-graded result has %d correct, want 3", got.Result.CorrectAnswers)
+	}
+	if !got.Result.SubmittedAt.Equal(original.SubmittedAt) || got.Result.Submission != models.SubmissionGracePeriod {
+		t.Fatalf("re-graded result was submitted %s at %v, want %s at %v",
+			got.Result.Submission, got.Result.SubmittedAt, models.SubmissionGracePeriod, original.SubmittedAt)
+	}
+	if len(got.ResultHistory) != 1 || got.ResultHistory[0].CorrectAnswers != 2 {
+		t.Fatalf("result history = %+v, want the original result", got.ResultHistory)
+	}
+
+	if got, err = service.ReviseAnswerKey(exam.ID, token, "key-v3.txt", testAnswerKey()); err != nil {
+		t.Fatalf("second ReviseAnswerKey: %v", err)
+	}
+	if len(got.ResultHistory) != 2 || got.Result.CorrectAnswers != 2 {
+		t.Fatalf("after a second revision: %d old results and %d correct, want 2 and 2", len(got.ResultHistory), got.Result.CorrectAnswers)
+	}
+}
+
+func TestReviseAnswerKeyRejections(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{})
+	exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+
+	if _, err := service.ReviseAnswerKey(exam.ID, "not-the-token", "key-v2.txt", testAnswerKey()); !errors.Is(err, ErrNotOwner) {
+		t.Errorf("revision by a non-owner: got %v, want ErrNotOwner", err)
+	}
+
+	// Stored answers are only comparable with a key over the same options
+	mismatched := testAnswerKey()
+	optionSet, err := models.ParseOptionSet(models.OptionSetAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatched.OptionSet = optionSet
+	if _, err := service.ReviseAnswerKey(exam.ID, token, "key-v2.txt", mismatched); !errors.Is(err, ErrInvalidExam) {
+		t.Errorf("revision with another option set: got %v, want ErrInvalidExam", err)
+	}
+
+	if got := getExam(t, service, exam.ID); got.KeyRevision != exam.KeyRevision || got.AnswerKeyPath != "key.txt" {
+		t.Fatalf("refused revisions changed the exam to revision %d at %s", got.KeyRevision, got.AnswerKeyPath)
+	}
+}
+
+func TestReviseAnswerKeyRegradesArchivedExam(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{})
+	exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+	if _, err := service.StartExam(exam.ID); err != nil {
+		t.Fatalf("StartExam: %v", err)
+	}
+	if _, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "B"}); err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if _, err := service.ArchiveExam(exam.ID, token); err != nil {
+		t.Fatalf("ArchiveExam: %v", err)
+	}
+
+	revised := testAnswerKey()
+	revised.Questions[0].Answer = "B"
+	got, err := service.ReviseAnswerKey(exam.ID, token, "key-v2.txt", revised)
+	if err != nil {
+		t.Fatalf("ReviseAnswerKey: %v", err)
+	}
+	if got.Status != models.StatusArchived || got.Result.CorrectAnswers != 1 || len(got.ResultHistory) != 1 {
+		t.Fatalf("exam is %s with %d correct and %d old results, want archived, re-graded to 1 correct",
+			got.Status, got.Result.CorrectAnswers, len(got.ResultHistory))
+	}
+}
+
+func TestGetExamMetadataUsesScoringWeights(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{})
+	scoring := models.ScoringConfig{Scheme: models.ScoringWeighted, Weights: map[string]float64{"2": 3}}
+	exam, _, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch, Scoring: scoring}, "exam.pdf", "key.txt", testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+
+	metadata, err := service.GetExamMetadata(exam.ID)
+	if err != nil {
+		t.Fatalf("GetExamMetadata: %v", err)
+	}
+
+	// The form shows the weights grading will use, so the scoring config wins over the key
+	want := []float64{1, 3, 1}
+	for i, question := range metadata.Questions {
+		if question.Weight != want[i] {
+			t.Errorf("question %d weight = %v, want %v", question.Number, question.Weight, want[i])
+		}
+	}
+}
+
+// countingRepository counts full listings of the wrapped repository
+type countingRepository struct {
+	repository.ExamRepository
+	lists int
+}
+
+func (r *countingRepository) List() ([]*models.Exam, error) {
+	r.lists++
+	return r.ExamRepository.List()
+}
+
+func TestIsExamPDFUsesIndex(t *testing.T) {
+	repo := &countingRepository{ExamRepository: repository.NewMemoryRepository()}
+	service := NewExamService(repo, NewPDFService(), NewFakeClock(testStart), SubmissionRules{})
+	t.Cleanup(service.Close)
+
+	dir := t.TempDir()
+	create := func(name string) (*models.Exam, string) {
+		exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, filepath.Join(dir, name+".pdf"), filepath.Join(dir, name+".txt"), testAnswerKey())
+		if err != nil {
+			t.Fatalf("CreateExam: %v", err)
+		}
+		return exam, token
+	}
+	isExamPDF := func(path string) bool {
+		t.Helper()
+		known, err := service.IsExamPDF(path)
+		if err != nil {
+			t.Fatalf("IsExamPDF: %v", err)
+		}
+		return known
+	}
+
+	// Exams stored before the first lookup are loaded once; later ones are indexed as they are created
+	before, _ := create("before")
+	if !isExamPDF(before.ExamPDFPath) || isExamPDF(before.AnswerKeyPath) {
+		t.Fatal("the index does not match the stored exam")
+	}
+	after, token := create("after")
+	if !isExamPDF(after.ExamPDFPath) {
+		t.Fatal("an exam created after the index was loaded is unknown")
+	}
+
+	if err := service.DeleteExam(after.ID, token); err != nil {
+		t.Fatalf("DeleteExam: %v", err)
+	}
+	if isExamPDF(after.ExamPDFPath) {
+		t.Fatal("a deleted exam is still known")
+	}
+
+	if repo.lists != 1 {
+		t.Fatalf("exams were listed %d times, want once", repo.lists)
+	}
+}
+
+func TestParseLatePolicy(t *testing.T) {
+	valid := map[string]LatePolicy{
+		"":           LatePolicyReject,
+		"reject":     LatePolicyReject,
+		"flag":       LatePolicyFlag,
+		" Autosaved": LatePolicyAutosaved,
+	}
+	for value, want := range valid {
+		if got, err := ParseLatePolicy(value); err != nil || got != want {
+			t.Errorf("ParseLatePolicy(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"flagged", "accept", "late"} {
+		if _, err := ParseLatePolicy(value); err == nil {
+			t.Errorf("ParseLatePolicy(%q) should have failed", value)
+		}
+	}
+}
+
+func TestFlagPolicyAfterTimerFired(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace, LatePolicy: LatePolicyFlag})
+	exam := startTimerExam(t, service)
+
+	if _, err := service.SaveAnswers(exam.ID, map[string]string{"1": "A"}, 0); err != nil {
+		t.Fatalf("SaveAnswers: %v", err)
+	}
+
+	// The timer still expires an abandoned exam and grades the stored answers
+	clock.Advance(testDuration + testGrace)
+	got := getExam(t, service, exam.ID)
+	if got.Status != models.StatusExpired || got.Result == nil || got.Result.CorrectAnswers != 1 {
+		t.Fatalf("exam is %s with result %+v, want expired with 1 correct", got.Status, got.Result)
+	}
+
+	// A later submission replaces that result and is flagged late
+	clock.Advance(time.Hour)
+	result, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A", "2": "B"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if result.Submission != models.SubmissionLate || result.LateBy != time.Hour+testGrace || result.CorrectAnswers != 2 {
+		t.Fatalf("result = %s late by %s with %d correct, want late by %s with 2 correct",
+			result.Submission, result.LateBy, result.CorrectAnswers, time.Hour+testGrace)
+	}
+
+	got = getExam(t, service, exam.ID)
+	if got.Status != models.StatusCompleted || got.Answers["2"] != "B" {
+		t.Fatalf("exam is %s with answers %v", got.Status, got.Answers)
+	}
+
+	if _, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"}); err == nil {
+		t.Fatal("a second late submission was accepted")
+	}
+}
+
+func TestAutosavedPolicyAfterTimerFired(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace, LatePolicy: LatePolicyAutosaved})
+	exam := startTimerExam(t, service)
+
+	if _, err := service.SaveAnswers(exam.ID, map[string]string{"1": "A"}, 0); err != nil {
+		t.Fatalf("SaveAnswers: %v", err)
+	}
+
+	// The timer grades the stored answers; a later submission is recorded but does not change them
+	clock.Advance(testDuration + testGrace)
+	clock.Advance(time.Minute)
+	result, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A", "2": "B", "3": "C"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if result.Submission != models.SubmissionLateAutosaved || result.CorrectAnswers != 1 {
+		t.Fatalf("result = %s with %d correct, want late_autosaved with 1 correct", result.Submission, result.CorrectAnswers)
+	}
+
+	got := getExam(t, service, exam.ID)
+	if got.Status != models.StatusExpired || !got.EndTime.Equal(testStart.Add(testDuration)) {
+		t.Fatalf("exam is %s ending at %v", got.Status, got.EndTime)
+	}
+
+	if _, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"}); err == nil {
+		t.Fatal("a second late submission was accepted")
+	}
+}
+
+func TestAutosavedPolicyAtTimerBoundary(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{GracePeriod: testGrace, LatePolicy: LatePolicyAutosaved})
+	exam := startTimerExam(t, service)
+
+	// Submitting at the very instant the timer fired must not reopen the exam as a grace period submission
+	clock.Advance(testDuration + testGrace)
+	result, err := service.SubmitAnswers(exam.ID, map[string]string{"1": "A"})
+	if err != nil {
+		t.Fatalf("SubmitAnswers: %v", err)
+	}
+	if result.Submission != models.SubmissionLateAutosaved {
+		t.Fatalf("result = %s, want late_autosaved", result.Submission)
+	}
+}
+
+func TestListExamsScopedToOwner(t *testing.T) {
+	service, _ := newTestService(t, SubmissionRules{})
+
+	var tokens []string
+	for i := 0; i < 3; i++ {
+		_, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, "exam.pdf", "key.txt", testAnswerKey())
+		if err != nil {
+			t.Fatalf("CreateExam: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	tests := []struct {
+		tokens []string
+		want   int
+	}{
+		{nil, 0},
+		{[]string{"not-a-token"}, 0},
+		{tokens[:1], 1},
+		{tokens, 3},
+	}
+
+	for _, tt := range tests {
+		page, err := service.ListExams(repository.ExamQuery{}, tt.tokens)
+		if err != nil {
+			t.Fatalf("ListExams: %v", err)
+		}
+		if len(page.Exams) != tt.want {
+			t.Errorf("ListExams with %d token(s) returned %d exams, want %d", len(tt.tokens), len(page.Exams), tt.want)
+		}
+	}
+}
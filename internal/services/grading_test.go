@@ -0,0 +1,89 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exam-helper/internal/models"
+	"exam-helper/internal/repository"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// goldenExam is a completed exam whose key is stored out of order, as older versions did
+func goldenExam() *models.Exam {
+	start := testStart
+	end := start.Add(25 * time.Minute)
+	duration := testDuration
+
+	return &models.Exam{
+		ID:        "golden-exam",
+		Mode:      models.ModeTimer,
+		Status:    models.StatusCompleted,
+		Duration:  &duration,
+		StartTime: &start,
+		EndTime:   &end,
+		OptionSet: models.DefaultOptionSet(),
+		AnswerKey: &models.AnswerKey{
+			Questions: []models.AnswerKeyQuestion{
+				{Number: 10, Answer: "E", Weight: 2, Section: "Matemática"},
+				{Number: 2, Answer: "B", Weight: 1, Section: "Português"},
+				{Number: 1, Answer: "A", Weight: 1, Section: "Português"},
+				{Number: 3, Answer: models.AnnulledAnswer, Weight: 1, Section: "Português", Annulled: true},
+				{Number: 9, Answer: "B", Weight: 2, Section: "Matemática", Accepted: []string{"B", "D"}},
+			},
+			OptionSet: models.DefaultOptionSet(),
+			Sections: []models.AnswerKeySection{
+				{Name: "Português", Weight: 1},
+				{Name: "Matemática", Weight: 2},
+			},
+			Checksum: "golden",
+		},
+		Answers:   map[string]string{"1": "A", "2": "C", "9": "D", "10": "E"},
+		CreatedAt: start,
+		UpdatedAt: end,
+	}
+}
+
+func TestGradeExamGolden(t *testing.T) {
+	// A fixed clock keeps GradedAt stable
+	service := NewExamService(repository.NewMemoryRepository(), NewPDFService(), NewFakeClock(testStart.Add(time.Hour)), SubmissionRules{})
+	defer service.Close()
+
+	var outputs [][]byte
+	for i := 0; i < 2; i++ {
+		result, err := service.gradeExam(goldenExam())
+		if err != nil {
+			t.Fatalf("gradeExam: %v", err)
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, append(data, '\n'))
+	}
+
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Fatalf("grading the same exam twice produced different JSON:\n%s\n%s", outputs[0], outputs[1])
+	}
+
+	golden := filepath.Join("testdata", "result.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, outputs[0], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(outputs[0], want) {
+		t.Errorf("result JSON differs from %s; run with -update if the change is intended\ngot:\n%s", golden, outputs[0])
+	}
+}
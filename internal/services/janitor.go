@@ -0,0 +1,162 @@
+package services
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StagingDirName is the directory inside the upload directory where uploads wait until their request succeeds
+const StagingDirName = ".staging"
+
+// Janitor periodically purges archived exams and upload files no exam refers to
+// Both are only removed once older than the retention period, so uploads still being processed are safe
+type Janitor struct {
+	examService *ExamService
+	uploadDir   string
+	retention   time.Duration
+	interval    time.Duration
+	clock       Clock
+	timer       Timer
+	stopped     bool
+	mutex       sync.Mutex
+}
+
+// NewJanitor creates a janitor that sweeps every interval
+func NewJanitor(examService *ExamService, uploadDir string, clock Clock, retention, interval time.Duration) *Janitor {
+	return &Janitor{
+		examService: examService,
+		uploadDir:   uploadDir,
+		retention:   retention,
+		interval:    interval,
+		clock:       clock,
+	}
+}
+
+// Start arms the first sweep; a non-positive interval leaves the janitor idle
+func (j *Janitor) Start() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.interval <= 0 || j.stopped {
+		return
+	}
+
+	j.timer = j.clock.AfterFunc(j.interval, j.run)
+}
+
+// Stop cancels the next sweep
+func (j *Janitor) Stop() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.stopped = true
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+}
+
+// run sweeps once and re-arms the timer
+func (j *Janitor) run() {
+	j.Sweep()
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if !j.stopped {
+		j.timer = j.clock.AfterFunc(j.interval, j.run)
+	}
+}
+
+// Sweep purges archived exams and orphaned upload files older than the retention period
+func (j *Janitor) Sweep() {
+	cutoff := j.clock.Now().Add(-j.retention)
+
+	purged, err := j.examService.PurgeArchived(cutoff)
+	if err != nil {
+		log.Printf("Janitor failed to purge archived exams: %v", err)
+	}
+
+	removed, err := j.removeOrphanedFiles(cutoff)
+	if err != nil {
+		log.Printf("Janitor failed to remove orphaned files: %v", err)
+	}
+
+	// Requests normally clean up their own staging directory; this catches a server that died mid-upload
+	if err := j.removeStaleStaging(cutoff); err != nil {
+		log.Printf("Janitor failed to remove stale staged uploads: %v", err)
+	}
+
+	if purged > 0 || removed > 0 {
+		log.Printf("Janitor purged %d archived exam(s) and %d orphaned file(s)", purged, removed)
+	}
+}
+
+// removeOrphanedFiles deletes files in the upload directory that no exam refers to and were last modified before cutoff
+func (j *Janitor) removeOrphanedFiles(cutoff time.Time) (int, error) {
+	referenced, err := j.examService.UploadedFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(j.uploadDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path, err := filepath.Abs(filepath.Join(j.uploadDir, entry.Name()))
+		if err != nil || referenced[path] {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Janitor failed to remove %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// removeStaleStaging deletes staging directories last modified before cutoff
+func (j *Janitor) removeStaleStaging(cutoff time.Time) error {
+	stagingRoot := filepath.Join(j.uploadDir, StagingDirName)
+
+	entries, err := os.ReadDir(stagingRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(stagingRoot, entry.Name())); err != nil {
+			log.Printf("Janitor failed to remove %s: %v", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
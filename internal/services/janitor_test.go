@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exam-helper/internal/models"
+	"exam-helper/internal/repository"
+)
+
+const testRetention = 24 * time.Hour
+
+// createUploadedExam creates a stopwatch exam whose files live in uploadDir and returns it with its owner token
+func createUploadedExam(t *testing.T, service *ExamService, uploadDir, name string) (*models.Exam, string) {
+	t.Helper()
+	examPDF := writeUpload(t, uploadDir, name+".pdf", testStart)
+	answerKey := writeUpload(t, uploadDir, name+".txt", testStart)
+
+	exam, token, err := service.CreateExam(models.CreateExamRequest{Mode: models.ModeStopwatch}, examPDF, answerKey, testAnswerKey())
+	if err != nil {
+		t.Fatalf("CreateExam: %v", err)
+	}
+	return exam, token
+}
+
+// writeUpload writes a file into dir and sets its modification time, since the janitor compares it with the fake clock
+func writeUpload(t *testing.T, dir, name string, modified time.Time) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertExists(t *testing.T, path string, want bool) {
+	t.Helper()
+	_, err := os.Stat(path)
+	if exists := err == nil; exists != want {
+		t.Errorf("%s exists = %v, want %v", filepath.Base(path), exists, want)
+	}
+}
+
+func TestJanitorPurgesArchivedExams(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{})
+	uploadDir := t.TempDir()
+	janitor := NewJanitor(service, uploadDir, clock, testRetention, time.Hour)
+	janitor.Start()
+	t.Cleanup(janitor.Stop)
+
+	archived, token := createUploadedExam(t, service, uploadDir, "archived")
+	if _, err := service.ArchiveExam(archived.ID, token); err != nil {
+		t.Fatalf("ArchiveExam: %v", err)
+	}
+	kept, _ := createUploadedExam(t, service, uploadDir, "kept")
+
+	// Sweeps within the retention period leave the archived exam alone
+	clock.Advance(time.Hour)
+	if _, err := service.GetExam(archived.ID); err != nil {
+		t.Fatalf("archived exam purged before the retention period: %v", err)
+	}
+
+	clock.Advance(testRetention)
+	if _, err := service.GetExam(archived.ID); !errors.Is(err, repository.ErrExamNotFound) {
+		t.Fatalf("GetExam after the retention period: got %v, want ErrExamNotFound", err)
+	}
+	assertExists(t, archived.ExamPDFPath, false)
+	assertExists(t, archived.AnswerKeyPath, false)
+
+	// Exams that are not archived are never purged, however old
+	if _, err := service.GetExam(kept.ID); err != nil {
+		t.Fatalf("unarchived exam was purged: %v", err)
+	}
+	assertExists(t, kept.ExamPDFPath, true)
+	assertExists(t, kept.AnswerKeyPath, true)
+}
+
+func TestJanitorRemovesOrphanedFiles(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{})
+	uploadDir := t.TempDir()
+	janitor := NewJanitor(service, uploadDir, clock, testRetention, 0)
+
+	exam, _ := createUploadedExam(t, service, uploadDir, "referenced")
+	clock.Advance(testRetention + time.Hour)
+
+	cutoff := clock.Now().Add(-testRetention)
+	orphan := writeUpload(t, uploadDir, "orphan.pdf", cutoff.Add(-time.Minute))
+	recent := writeUpload(t, uploadDir, "recent.pdf", cutoff.Add(time.Minute))
+
+	janitor.Sweep()
+
+	assertExists(t, orphan, false)
+	assertExists(t, recent, true)
+	assertExists(t, exam.ExamPDFPath, true)
+	assertExists(t, exam.AnswerKeyPath, true)
+}
+
+func TestJanitorRemovesStaleStaging(t *testing.T) {
+	service, clock := newTestService(t, SubmissionRules{})
+	uploadDir := t.TempDir()
+	janitor := NewJanitor(service, uploadDir, clock, testRetention, 0)
+	clock.Advance(testRetention + time.Hour)
+	cutoff := clock.Now().Add(-testRetention)
+
+	stagingRoot := filepath.Join(uploadDir, StagingDirName)
+	dirs := map[string]time.Time{
+		"upload-stale":  cutoff.Add(-time.Minute),
+		"upload-recent": cutoff.Add(time.Minute),
+	}
+	for name, modified := range dirs {
+		dir := filepath.Join(stagingRoot, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeUpload(t, dir, "exam_pdf.pdf", modified)
+		if err := os.Chtimes(dir, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	janitor.Sweep()
+
+	assertExists(t, filepath.Join(stagingRoot, "upload-stale"), false)
+	assertExists(t, filepath.Join(stagingRoot, "upload-recent"), true)
+	assertExists(t, stagingRoot, true)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"path/filepath"
+	"sync"
+
+	"exam-helper/internal/models"
+)
+
+// examPDFIndex maps the absolute path of every stored exam PDF to its exam
+// It is loaded from the repository on first use, so serving a file does not list every exam
+// Updates must follow the repository write they mirror, so a concurrent load never loses them
+type examPDFIndex struct {
+	paths map[string]string // nil until loaded
+	mutex sync.Mutex
+}
+
+// Contains reports whether path is a known exam PDF, loading the index with load if needed
+func (i *examPDFIndex) Contains(path string, load func() ([]*models.Exam, error)) (bool, error) {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.paths == nil {
+		exams, err := load()
+		if err != nil {
+			return false, err
+		}
+
+		paths := make(map[string]string, len(exams))
+		for _, exam := range exams {
+			if abs, ok := absPath(exam.ExamPDFPath); ok {
+				paths[abs] = exam.ID
+			}
+		}
+		i.paths = paths
+	}
+
+	_, ok := i.paths[target]
+	return ok, nil
+}
+
+// Add records the exam PDF of a stored exam
+func (i *examPDFIndex) Add(exam *models.Exam) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if abs, ok := absPath(exam.ExamPDFPath); ok && i.paths != nil {
+		i.paths[abs] = exam.ID
+	}
+}
+
+// Remove forgets the exam PDF of a deleted exam
+func (i *examPDFIndex) Remove(exam *models.Exam) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if abs, ok := absPath(exam.ExamPDFPath); ok && i.paths[abs] == exam.ID {
+		delete(i.paths, abs)
+	}
+}
+
+// absPath returns the absolute form of a stored path; empty paths have none
+func absPath(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+
+	abs, err := filepath.Abs(path)
+	return abs, err == nil
+}
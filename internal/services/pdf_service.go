@@ -2,12 +2,22 @@ package services
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"exam-helper/internal/models"
+	"exam-helper/internal/pdf"
 )
 
 // PDFService handles PDF processing operations
@@ -18,126 +28,583 @@ func NewPDFService() *PDFService {
 	return &PDFService{}
 }
 
-// ParseAnswerKey extracts answer key from a PDF file
-// For now, this is a simplified implementation that expects a text file format
-// In a production environment, you would use a PDF parsing library
-func (s *PDFService) ParseAnswerKey(filePath string) (map[string]string, error) {
+// answerKeyPatterns match the supported answer key line formats
+// The answer token is checked against the exam's option set afterwards
+var answerKeyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^\s*(\d+)\s*[.)\-:]\s*(\S+)\s*$`),                    // "1. A" or "1) A" or "1: A"
+	regexp.MustCompile(`(?i)^\s*[Qq](?:uestion)?\s*(\d+)\s*[.)\-:]\s*(\S+)\s*$`), // "Q1. A" or "Question 1: A"
+	regexp.MustCompile(`(?i)^\s*(\d+)\s+(\S+)\s*$`),                              // "1 A"
+}
+
+// ErrStrictAnswerKey is returned when strict mode finds problems in an answer key
+var ErrStrictAnswerKey = errors.New("answer key has problems that strict mode does not allow")
+
+// weightPattern matches a trailing weight annotation such as "weight=2" or "peso=1,5"
+var weightPattern = regexp.MustCompile(`(?i)(?:^|\s)(?:weight|peso)\s*=\s*(\S+)\s*$`)
+
+// ParseAnswerKey extracts the answer key from a TXT or PDF file
+// PDF files have their page text extracted first; both formats then go through the same line patterns
+func (s *PDFService) ParseAnswerKey(filePath string, optionSet models.OptionSet) (*models.AnswerKey, []models.ParseDiagnostic, error) {
 	if filePath == "" {
-		return nil, errors.New("file path is required")
+		return nil, nil, errors.New("file path is required")
 	}
 
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file does not exist: %s", filePath)
+		return nil, nil, fmt.Errorf("file does not exist: %s", filePath)
 	}
 
-	file, err := os.Open(filePath)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
 	}
-	defer file.Close()
 
-	answerKey := make(map[string]string)
-	scanner := bufio.NewScanner(file)
+	return s.ParseAnswerKeyData(filepath.Base(filePath), data, optionSet)
+}
 
-	// Regular expressions to match different answer key formats
-	patterns := []*regexp.Regexp{
-		regexp.MustCompile(`(?i)^\s*(\d+)\s*[.)\-:]\s*([A-Ea-e])\s*$`),                    // "1. A" or "1) A" or "1: A"
-		regexp.MustCompile(`(?i)^\s*[Qq](?:uestion)?\s*(\d+)\s*[.)\-:]\s*([A-Ea-e])\s*$`), // "Q1. A" or "Question 1: A"
-		regexp.MustCompile(`(?i)^\s*(\d+)\s+([A-Ea-e])\s*$`),                              // "1 A"
+// ParseAnswerKeyData parses an answer key held in memory; name is only used to detect the file format
+// Diagnostics are returned even when parsing fails, so callers can explain what went wrong
+func (s *PDFService) ParseAnswerKeyData(name string, data []byte, optionSet models.OptionSet) (*models.AnswerKey, []models.ParseDiagnostic, error) {
+	text, err := answerKeyText(name, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	answerKey, diagnostics, err := parseAnswerKeyText(text, optionSet)
+	if err != nil {
+		return nil, diagnostics, err
 	}
 
+	sum := sha256.Sum256(data)
+	answerKey.Checksum = hex.EncodeToString(sum[:])
+	answerKey.SourceFile = name
+	answerKey.ParsedAt = time.Now()
+
+	return answerKey, diagnostics, nil
+}
+
+// EnforceStrict rejects an answer key with any warning or error diagnostic
+func (s *PDFService) EnforceStrict(diagnostics []models.ParseDiagnostic) error {
+	problems := 0
+	for _, d := range diagnostics {
+		if d.Severity != models.SeverityInfo {
+			problems++
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%w: %d issue(s) found", ErrStrictAnswerKey, problems)
+	}
+
+	return nil
+}
+
+// parseAnswerKeyText matches every line of an answer key against the supported patterns
+func parseAnswerKeyText(text string, optionSet models.OptionSet) (*models.AnswerKey, []models.ParseDiagnostic, error) {
+	questions := make(map[int]models.AnswerKeyQuestion)
+	definedOn := make(map[int]int)
+	diagnostics := make([]models.ParseDiagnostic, 0)
+	answerKey := &models.AnswerKey{OptionSet: optionSet}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+
+	// Questions inherit the section and weight of the last "##" header
+	section := ""
+	sectionWeight := 1.0
+
 	lineNumber := 0
 	for scanner.Scan() {
 		lineNumber++
 		line := strings.TrimSpace(scanner.Text())
 
+		// Section headers, e.g. "## Matemática weight=2"
+		if strings.HasPrefix(line, "##") {
+			name, weight, err := splitWeight(strings.TrimSpace(strings.TrimLeft(line, "#")))
+			if err != nil {
+				diagnostics = append(diagnostics, models.ParseDiagnostic{
+					Line:     lineNumber,
+					Text:     line,
+					Reason:   err.Error(),
+					Severity: models.SeverityWarning,
+				})
+				weight = 1
+			}
+
+			section = name
+			sectionWeight = weight
+			answerKey.Sections = append(answerKey.Sections, models.AnswerKeySection{Name: name, Weight: weight})
+			continue
+		}
+
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
 			continue
 		}
 
+		// A trailing "weight=N" overrides the section weight for one question
+		content, weight, err := splitWeight(line)
+		if err != nil {
+			diagnostics = append(diagnostics, models.ParseDiagnostic{
+				Line:     lineNumber,
+				Text:     line,
+				Reason:   err.Error(),
+				Severity: models.SeverityWarning,
+			})
+			continue
+		}
+		if content == line {
+			weight = sectionWeight
+		}
+
 		// Try to match against different patterns
-		var questionNum, answer string
+		var questionText, answer string
 		matched := false
 
-		for _, pattern := range patterns {
-			matches := pattern.FindStringSubmatch(line)
+		for _, pattern := range answerKeyPatterns {
+			matches := pattern.FindStringSubmatch(content)
 			if len(matches) >= 3 {
-				questionNum = matches[1]
-				answer = strings.ToUpper(matches[2])
+				questionText = matches[1]
+				answer = matches[2]
 				matched = true
 				break
 			}
 		}
 
-		if matched {
-			answerKey[questionNum] = answer
-		} else {
-			// Log warning but continue processing
-			fmt.Printf("Warning: Could not parse line %d: '%s'\n", lineNumber, line)
+		if !matched {
+			diagnostics = append(diagnostics, models.ParseDiagnostic{
+				Line:     lineNumber,
+				Text:     line,
+				Reason:   "line does not match any supported answer format",
+				Severity: models.SeverityWarning,
+			})
+			continue
+		}
+
+		// Question numbers start at 1, and one too large for an int is not a question number either
+		questionNum, err := strconv.Atoi(questionText)
+		if err != nil || questionNum < 1 {
+			diagnostics = append(diagnostics, models.ParseDiagnostic{
+				Line:     lineNumber,
+				Text:     line,
+				Reason:   "invalid question number",
+				Severity: models.SeverityWarning,
+			})
+			continue
+		}
+
+		// Annulled questions, e.g. "7. X" or "7. ANULADA"; a real option named X still wins
+		option, ok := optionSet.Normalize(answer)
+		annulled := !ok && models.IsAnnulledMarker(answer)
+		if annulled {
+			option, ok = models.AnnulledAnswer, true
+		}
+
+		// Several accepted options, e.g. "12. B/D"
+		var accepted []string
+		if !ok {
+			accepted, ok = splitAccepted(answer, optionSet)
+			if ok {
+				option = accepted[0]
+			}
+		}
+		if !ok {
+			diagnostics = append(diagnostics, models.ParseDiagnostic{
+				Line:     lineNumber,
+				Text:     line,
+				Reason:   fmt.Sprintf("answer %q is not a valid option. Expected %s", answer, optionSet),
+				Severity: models.SeverityWarning,
+			})
+			continue
+		}
+
+		if previous, exists := definedOn[questionNum]; exists {
+			diagnostics = append(diagnostics, models.ParseDiagnostic{
+				Line:     lineNumber,
+				Text:     line,
+				Reason:   fmt.Sprintf("question %d is already defined on line %d; this answer replaces it", questionNum, previous),
+				Severity: models.SeverityWarning,
+			})
 		}
+
+		questions[questionNum] = models.AnswerKeyQuestion{
+			Number:   questionNum,
+			Answer:   option,
+			Weight:   weight,
+			Section:  section,
+			Annulled: annulled,
+			Accepted: accepted,
+		}
+		definedOn[questionNum] = lineNumber
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return nil, diagnostics, fmt.Errorf("error reading file: %w", err)
 	}
 
-	if len(answerKey) == 0 {
-		return nil, errors.New("no valid answers found in the answer key file")
+	if len(questions) == 0 {
+		return nil, diagnostics, errors.New("no valid answers found in the answer key file")
 	}
 
-	return answerKey, nil
+	answerKey.Questions = make([]models.AnswerKeyQuestion, 0, len(questions))
+	for _, question := range questions {
+		answerKey.Questions = append(answerKey.Questions, question)
+	}
+	sort.Slice(answerKey.Questions, func(i, j int) bool {
+		return answerKey.Questions[i].Number < answerKey.Questions[j].Number
+	})
+
+	diagnostics = append(diagnostics, numberingGaps(answerKey)...)
+
+	return answerKey, diagnostics, nil
 }
 
-// ValidateAnswerKeyFormat checks if the answer key has a valid format
-func (s *PDFService) ValidateAnswerKeyFormat(filePath string) error {
-	answerKey, err := s.ParseAnswerKey(filePath)
-	if err != nil {
-		return err
+// splitAccepted parses a list of accepted options such as "B/D" or "B,D"
+// It fails unless there are at least two distinct options and all of them are valid
+func splitAccepted(answer string, optionSet models.OptionSet) ([]string, bool) {
+	parts := strings.FieldsFunc(answer, func(r rune) bool { return r == '/' || r == ',' })
+	if len(parts) < 2 {
+		return nil, false
 	}
 
-	if len(answerKey) == 0 {
-		return errors.New("answer key is empty")
+	accepted := make([]string, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		option, ok := optionSet.Normalize(part)
+		if !ok {
+			return nil, false
+		}
+		if !seen[option] {
+			seen[option] = true
+			accepted = append(accepted, option)
+		}
 	}
 
-	// Validate that all answers are valid multiple choice options (A-E)
-	validAnswers := map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true}
+	if len(accepted) < 2 {
+		return nil, false
+	}
+
+	return accepted, true
+}
+
+// splitWeight removes a trailing "weight=N" (or "peso=N") annotation, returning the rest of the text
+// and the weight; text without an annotation is returned unchanged with a weight of 1
+func splitWeight(text string) (string, float64, error) {
+	matches := weightPattern.FindStringSubmatchIndex(text)
+	if matches == nil {
+		return text, 1, nil
+	}
 
-	for questionNum, answer := range answerKey {
-		if !validAnswers[answer] {
-			return fmt.Errorf("invalid answer '%s' for question %s. Expected A, B, C, D, or E", answer, questionNum)
+	value := strings.Replace(text[matches[2]:matches[3]], ",", ".", 1)
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || weight <= 0 {
+		return strings.TrimSpace(text[:matches[0]]), 1, fmt.Errorf("invalid weight %q. Expected a positive number", text[matches[2]:matches[3]])
+	}
+
+	return strings.TrimSpace(text[:matches[0]]), weight, nil
+}
+
+// numberingGaps reports missing question numbers between 1 and the highest question
+func numberingGaps(answerKey *models.AnswerKey) []models.ParseDiagnostic {
+	var diagnostics []models.ParseDiagnostic
+
+	for _, gap := range questionGaps(answerKey) {
+		reason := fmt.Sprintf("question %d is missing", gap.From)
+		if gap.To > gap.From {
+			reason = fmt.Sprintf("questions %d to %d are missing", gap.From, gap.To)
 		}
+		diagnostics = append(diagnostics, models.ParseDiagnostic{
+			Reason:   reason,
+			Severity: models.SeverityWarning,
+		})
+	}
+
+	return diagnostics
+}
+
+// QuestionGap is an inclusive range of question numbers missing from an answer key
+type QuestionGap struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
 
-		// Validate question number is numeric
-		if _, err := strconv.Atoi(questionNum); err != nil {
-			return fmt.Errorf("invalid question number '%s'. Expected numeric value", questionNum)
+// questionGaps returns the ranges of question numbers missing between 1 and the highest question
+func questionGaps(answerKey *models.AnswerKey) []QuestionGap {
+	gaps := make([]QuestionGap, 0)
+
+	expected := 1
+	for _, question := range answerKey.Questions {
+		if question.Number > expected {
+			gaps = append(gaps, QuestionGap{From: expected, To: question.Number - 1})
+		}
+		if question.Number >= expected {
+			expected = question.Number + 1
 		}
 	}
 
-	return nil
+	return gaps
 }
 
-// GetAnswerKeyPreview returns a preview of the parsed answer key for validation
-func (s *PDFService) GetAnswerKeyPreview(filePath string) (map[string]string, error) {
-	answerKey, err := s.ParseAnswerKey(filePath)
+// Codes identifying why an uploaded file was rejected
+const (
+	UploadEmpty        = "empty_file"
+	UploadNotPDF       = "not_pdf"
+	UploadEncryptedPDF = "encrypted_pdf"
+	UploadMalformedPDF = "malformed_pdf"
+	UploadNotText      = "invalid_text"
+	UploadTooLarge     = "file_too_large"
+)
+
+// UploadError is returned when the content of an uploaded file is not what its field requires
+type UploadError struct {
+	Code    string
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+// CheckExamPDF verifies that an uploaded exam file looks like an unencrypted PDF, whatever its name says
+// Exams are only shown to students, so the body is never parsed; only the start and end of the file are read
+func (s *PDFService) CheckExamPDF(path string) error {
+	file, err := os.Open(path)
 	if err != nil {
+		return fmt.Errorf("failed to open exam file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read exam file: %w", err)
+	}
+	if info.Size() == 0 {
+		return &UploadError{Code: UploadEmpty, Message: "exam file is empty"}
+	}
+
+	head, err := readAt(file, 0, min(info.Size(), pdf.SniffSize))
+	if err != nil {
+		return fmt.Errorf("failed to read exam file: %w", err)
+	}
+
+	tailSize := min(info.Size(), pdf.SniffSize)
+	tail, err := readAt(file, info.Size()-tailSize, tailSize)
+	if err != nil {
+		return fmt.Errorf("failed to read exam file: %w", err)
+	}
+
+	return pdfUploadError(pdf.Sniff(head, tail))
+}
+
+// readAt reads exactly size bytes starting at offset
+func readAt(file *os.File, offset, size int64) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, offset); err != nil {
 		return nil, err
 	}
+	return buf, nil
+}
+
+// CheckAnswerKeyFile verifies that an uploaded answer key is either a valid PDF or UTF-8 text
+// A file named .pdf must really be a PDF
+func (s *PDFService) CheckAnswerKeyFile(name string, data []byte) error {
+	if len(data) == 0 {
+		return &UploadError{Code: UploadEmpty, Message: "answer key file is empty"}
+	}
 
+	if pdf.HasHeader(data) || strings.EqualFold(filepath.Ext(name), ".pdf") {
+		return pdfUploadError(pdf.Validate(data))
+	}
+
+	// NUL bytes never appear in text files but are common in binary ones that happen to be valid UTF-8
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return &UploadError{Code: UploadNotText, Message: "answer key must be a PDF or a UTF-8 text file"}
+	}
+
+	return nil
+}
+
+// pdfUploadError maps PDF structure problems to upload errors
+func pdfUploadError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pdf.ErrNotPDF):
+		return &UploadError{Code: UploadNotPDF, Message: "file content is not a PDF document"}
+	case errors.Is(err, pdf.ErrEncrypted):
+		return &UploadError{Code: UploadEncryptedPDF, Message: "encrypted or password-protected PDFs are not supported"}
+	default:
+		return &UploadError{Code: UploadMalformedPDF, Message: fmt.Sprintf("PDF document is damaged: %v", err)}
+	}
+}
+
+// answerKeyText returns the textual content of an answer key file
+func answerKeyText(name string, data []byte) (string, error) {
+	if strings.EqualFold(filepath.Ext(name), ".pdf") || pdf.HasHeader(data) {
+		text, err := pdf.ExtractText(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from PDF: %w", err)
+		}
+		return text, nil
+	}
+
+	return string(data), nil
+}
+
+// ValidateAnswerKeyFormat checks if the answer key has a valid format
+func (s *PDFService) ValidateAnswerKeyFormat(answerKey *models.AnswerKey) error {
+	if answerKey == nil || len(answerKey.Questions) == 0 {
+		return errors.New("answer key is empty")
+	}
+
+	// Validate that all answers belong to the exam's option set
+	for _, question := range answerKey.Questions {
+		if !question.Annulled {
+			for _, answer := range question.AcceptedAnswers() {
+				if option, ok := answerKey.OptionSet.Normalize(answer); !ok || option != answer {
+					return fmt.Errorf("invalid answer '%s' for question %d. Expected %s", answer, question.Number, answerKey.OptionSet)
+				}
+			}
+		}
+
+		// Validate question number is positive
+		if question.Number <= 0 {
+			return fmt.Errorf("invalid question number '%d'. Expected a positive value", question.Number)
+		}
+	}
+
+	return nil
+}
+
+// AnswerKeyAnalysis summarises a parsed answer key for dry-run validation
+type AnswerKeyAnalysis struct {
+	QuestionCount      int                      `json:"question_count"`
+	OptionDistribution map[string]int           `json:"option_distribution"`
+	Diagnostics        []models.ParseDiagnostic `json:"diagnostics"`
+}
+
+// AnalyzeAnswerKey counts answers per option and flags suspicious but legal patterns as info diagnostics
+func (s *PDFService) AnalyzeAnswerKey(answerKey *models.AnswerKey) *AnswerKeyAnalysis {
+	analysis := &AnswerKeyAnalysis{
+		QuestionCount:      len(answerKey.Questions),
+		OptionDistribution: make(map[string]int, len(answerKey.OptionSet.Options)),
+		Diagnostics:        make([]models.ParseDiagnostic, 0),
+	}
+
+	for _, option := range answerKey.OptionSet.Options {
+		analysis.OptionDistribution[option] = 0
+	}
+
+	// Annulled questions have no correct option and break runs
+	answered := 0
+	runLength := 0
+	for i, question := range answerKey.Questions {
+		if question.Annulled {
+			runLength = 0
+			continue
+		}
+		answered++
+		for _, answer := range question.AcceptedAnswers() {
+			analysis.OptionDistribution[answer]++
+		}
+
+		if i > 0 && question.AnswerText() == answerKey.Questions[i-1].AnswerText() {
+			runLength++
+		} else {
+			runLength = 1
+		}
+		if runLength == 5 {
+			analysis.Diagnostics = append(analysis.Diagnostics, models.ParseDiagnostic{
+				Reason:   fmt.Sprintf("question %d starts a run of %s answers", answerKey.Questions[i-4].Number, question.AnswerText()),
+				Severity: models.SeverityInfo,
+			})
+		}
+	}
+
+	// Only comment on the distribution when there are enough questions for it to mean something
+	if answered >= 10 {
+		// An even split is 1/n; flag options chosen at least twice as often
+		threshold := 2 / float64(len(answerKey.OptionSet.Options))
+		for _, option := range answerKey.OptionSet.Options {
+			count := analysis.OptionDistribution[option]
+			share := float64(count) / float64(answered)
+
+			switch {
+			case count == 0:
+				analysis.Diagnostics = append(analysis.Diagnostics, models.ParseDiagnostic{
+					Reason:   fmt.Sprintf("option %s is never the correct answer", option),
+					Severity: models.SeverityInfo,
+				})
+			case share >= threshold && threshold < 1:
+				analysis.Diagnostics = append(analysis.Diagnostics, models.ParseDiagnostic{
+					Reason:   fmt.Sprintf("option %s is the correct answer for %.0f%% of the questions", option, share*100),
+					Severity: models.SeverityInfo,
+				})
+			}
+		}
+	}
+
+	return analysis
+}
+
+// AnswerKeyMetadata describes the questions of an exam without revealing their answers
+type AnswerKeyMetadata struct {
+	TotalQuestions  int                `json:"total_questions"`
+	QuestionNumbers []int              `json:"question_numbers"` // Ordered; may skip numbers listed in Gaps
+	Questions       []QuestionMetadata `json:"questions"`
+	Gaps            []QuestionGap      `json:"gaps"`
+	OptionSet       string             `json:"option_set"` // Option set name, e.g. "A-E" or "C/E"
+	Sections        []string           `json:"sections,omitempty"`
+}
+
+// QuestionMetadata describes a single question for the answer form
+type QuestionMetadata struct {
+	Number  int      `json:"number"`
+	Options []string `json:"options"`
+	Section string   `json:"section,omitempty"`
+	Weight  float64  `json:"weight"`
+}
+
+// GetAnswerKeyMetadata returns what the answer form needs to render every question of the key
+// Weights come from scoring when it sets them, the same way grading picks them
+func (s *PDFService) GetAnswerKeyMetadata(answerKey *models.AnswerKey, scoring models.ScoringConfig) *AnswerKeyMetadata {
+	metadata := &AnswerKeyMetadata{
+		TotalQuestions:  len(answerKey.Questions),
+		QuestionNumbers: make([]int, 0, len(answerKey.Questions)),
+		Questions:       make([]QuestionMetadata, 0, len(answerKey.Questions)),
+		Gaps:            questionGaps(answerKey),
+		OptionSet:       answerKey.OptionSet.Name,
+	}
+
+	for _, section := range answerKey.Sections {
+		metadata.Sections = append(metadata.Sections, section.Name)
+	}
+
+	// Every question currently shares the exam's option set
+	for _, question := range answerKey.Questions {
+		metadata.QuestionNumbers = append(metadata.QuestionNumbers, question.Number)
+		metadata.Questions = append(metadata.Questions, QuestionMetadata{
+			Number:  question.Number,
+			Options: answerKey.OptionSet.Options,
+			Section: question.Section,
+			Weight:  questionWeight(scoring, question),
+		})
+	}
+
+	return metadata
+}
+
+// GetAnswerKeyPreview returns a preview of the parsed answer key for validation
+func (s *PDFService) GetAnswerKeyPreview(answerKey *models.AnswerKey) map[string]string {
 	// Return first 10 questions for preview
 	preview := make(map[string]string)
-	count := 0
 	maxPreview := 10
 
-	// Sort question numbers and show first few
-	for i := 1; i <= maxPreview && count < len(answerKey); i++ {
-		questionNum := strconv.Itoa(i)
-		if answer, exists := answerKey[questionNum]; exists {
-			preview[questionNum] = answer
-			count++
+	// Questions are sorted, so the preview holds the lowest question numbers
+	for _, question := range answerKey.Questions {
+		if len(preview) >= maxPreview {
+			break
 		}
+		preview[strconv.Itoa(question.Number)] = question.AnswerText()
 	}
 
-	return preview, nil
+	return preview
 }
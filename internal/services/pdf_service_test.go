@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"exam-helper/internal/models"
+)
+
+func TestCheckExamPDF(t *testing.T) {
+	// The body of an exam is never parsed, so only the ends of this file matter
+	large := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("["), 4<<20)...)
+	large = append(large, "\n%%EOF\n"...)
+
+	tests := []struct {
+		name string
+		data []byte
+		code string
+	}{
+		{"large body", large, ""},
+		{"empty", nil, UploadEmpty},
+		{"text", []byte("1 - A\n2 - B\n"), UploadNotPDF},
+		{"truncated", large[:len(large)-10], UploadMalformedPDF},
+		{"encrypted", []byte("%PDF-1.4\ntrailer\n<</Root 1 0 R /Encrypt 2 0 R>>\n%%EOF\n"), UploadEncryptedPDF},
+	}
+
+	service := NewPDFService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "exam.pdf")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := service.CheckExamPDF(path)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var uploadErr *UploadError
+			if !errors.As(err, &uploadErr) || uploadErr.Code != tt.code {
+				t.Fatalf("got %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestCheckAnswerKeyFile(t *testing.T) {
+	keyPDF, err := os.ReadFile(filepath.Join("..", "pdf", "testdata", "flate.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		code     string
+	}{
+		{"text", "gabarito.txt", []byte("1. A\n2. B\n"), ""},
+		{"accented text", "gabarito.txt", []byte("Questão 1 – A\n"), ""},
+		{"pdf", "gabarito.pdf", keyPDF, ""},
+		{"pdf named as text", "gabarito.txt", keyPDF, ""},
+		{"empty", "gabarito.txt", nil, UploadEmpty},
+		{"text named as pdf", "gabarito.pdf", []byte("1. A\n2. B\n"), UploadNotPDF},
+		{"nul byte", "gabarito.txt", []byte("1. A\n\x002. B\n"), UploadNotText},
+		{"invalid utf-8", "gabarito.txt", []byte("1. A\n2. \xff\xfe\n"), UploadNotText},
+		{"encrypted pdf", "gabarito.pdf", []byte("%PDF-1.4\ntrailer\n<</Root 1 0 R /Encrypt 2 0 R>>\n%%EOF\n"), UploadEncryptedPDF},
+		{"truncated pdf", "gabarito.pdf", keyPDF[:len(keyPDF)/2], UploadMalformedPDF},
+	}
+
+	service := NewPDFService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.CheckAnswerKeyFile(tt.fileName, tt.data)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var uploadErr *UploadError
+			if !errors.As(err, &uploadErr) || uploadErr.Code != tt.code {
+				t.Fatalf("got %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestParseAnswerKeyInvalidQuestionNumbers(t *testing.T) {
+	data := []byte("0. A\n1. A\n2. B\n99999999999999999999. B\n")
+
+	service := NewPDFService()
+	answerKey, diagnostics, err := service.ParseAnswerKeyData("gabarito.txt", data, models.DefaultOptionSet())
+	if err != nil {
+		t.Fatalf("ParseAnswerKeyData: %v", err)
+	}
+
+	if len(answerKey.Questions) != 2 || answerKey.Questions[0].Number != 1 || answerKey.Questions[1].Number != 2 {
+		t.Fatalf("questions = %+v, want 1 and 2", answerKey.Questions)
+	}
+	if err := service.ValidateAnswerKeyFormat(answerKey); err != nil {
+		t.Fatalf("ValidateAnswerKeyFormat: %v", err)
+	}
+
+	// Only the two bad lines are reported; no gap warning comes from the overflowing number
+	if len(diagnostics) != 2 {
+		t.Fatalf("diagnostics = %+v, want 2", diagnostics)
+	}
+	for i, line := range []int{1, 4} {
+		if d := diagnostics[i]; d.Line != line || d.Reason != "invalid question number" || d.Severity != models.SeverityWarning {
+			t.Errorf("diagnostic %d = %+v, want an invalid question number warning on line %d", i, d, line)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// DeadlineScheduler fires a callback when an exam deadline is reached
+// Deadlines are derived from stored exam data, so they can be re-armed after a restart
+type DeadlineScheduler struct {
+	clock      Clock
+	timers     map[string]Timer
+	mutex      sync.Mutex
+	onDeadline func(examID string)
+}
+
+// NewDeadlineScheduler creates a scheduler that calls onDeadline for each expired exam
+func NewDeadlineScheduler(clock Clock, onDeadline func(examID string)) *DeadlineScheduler {
+	return &DeadlineScheduler{
+		clock:      clock,
+		timers:     make(map[string]Timer),
+		onDeadline: onDeadline,
+	}
+}
+
+// Schedule arms (or re-arms) the deadline for an exam; past deadlines fire immediately
+func (s *DeadlineScheduler) Schedule(examID string, deadline time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if timer, exists := s.timers[examID]; exists {
+		timer.Stop()
+	}
+
+	delay := deadline.Sub(s.clock.Now())
+	if delay < 0 {
+		delay = 0
+	}
+
+	var timer Timer
+	timer = s.clock.AfterFunc(delay, func() {
+		s.mutex.Lock()
+		// Ignore a timer that was cancelled or replaced after it started firing
+		current, exists := s.timers[examID]
+		if !exists || current != timer {
+			s.mutex.Unlock()
+			return
+		}
+		delete(s.timers, examID)
+		s.mutex.Unlock()
+
+		s.onDeadline(examID)
+	})
+	s.timers[examID] = timer
+}
+
+// Cancel disarms the deadline for an exam, if any
+func (s *DeadlineScheduler) Cancel(examID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if timer, exists := s.timers[examID]; exists {
+		timer.Stop()
+		delete(s.timers, examID)
+	}
+}
+
+// Stop disarms every pending deadline
+func (s *DeadlineScheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for examID, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, examID)
+	}
+}
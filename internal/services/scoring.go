@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+
+	"exam-helper/internal/models"
+)
+
+// Scorer turns graded question details into points
+type Scorer interface {
+	// Score returns the net points earned and the points of a perfect exam
+	Score(details []models.QuestionResult) (net, max float64)
+}
+
+// NewScorer validates a scoring configuration and returns its scorer
+// The zero configuration selects plain percentage scoring
+func NewScorer(config models.ScoringConfig) (Scorer, error) {
+	switch config.Scheme {
+	case "", models.ScoringPercentage:
+		return percentageScorer{}, nil
+	case models.ScoringNegative:
+		if config.Penalty <= 0 {
+			return nil, fmt.Errorf("%s scoring requires a positive penalty", config.Scheme)
+		}
+		return negativeScorer{penalty: config.Penalty}, nil
+	case models.ScoringCespe:
+		return negativeScorer{penalty: 1}, nil
+	case models.ScoringWeighted:
+		for question, weight := range config.Weights {
+			if weight < 0 {
+				return nil, fmt.Errorf("weight for question %s must not be negative", question)
+			}
+		}
+		return weightedScorer{}, nil
+	case models.ScoringWrongCancelsRight:
+		if config.WrongsPerRight <= 0 {
+			return nil, fmt.Errorf("%s scoring requires a positive wrongs_per_right", config.Scheme)
+		}
+		return wrongCancelsRightScorer{wrongsPerRight: config.WrongsPerRight}, nil
+	default:
+		return nil, fmt.Errorf("unknown scoring scheme %q", config.Scheme)
+	}
+}
+
+// percentageScorer awards one point per correct answer
+type percentageScorer struct{}
+
+func (percentageScorer) Score(details []models.QuestionResult) (float64, float64) {
+	correct, _, _ := tally(details)
+	return float64(correct), float64(len(details))
+}
+
+// negativeScorer awards one point per correct answer and subtracts penalty per wrong answer
+type negativeScorer struct {
+	penalty float64
+}
+
+func (s negativeScorer) Score(details []models.QuestionResult) (float64, float64) {
+	correct, wrong, _ := tally(details)
+	return float64(correct) - s.penalty*float64(wrong), float64(len(details))
+}
+
+// weightedScorer awards each correct answer its question weight
+type weightedScorer struct{}
+
+func (weightedScorer) Score(details []models.QuestionResult) (float64, float64) {
+	var net, max float64
+	for _, d := range details {
+		max += d.Weight
+		if d.IsCorrect {
+			net += d.Weight
+		}
+	}
+	return net, max
+}
+
+// wrongCancelsRightScorer removes one correct answer for every wrongsPerRight wrong answers
+type wrongCancelsRightScorer struct {
+	wrongsPerRight int
+}
+
+func (s wrongCancelsRightScorer) Score(details []models.QuestionResult) (float64, float64) {
+	correct, wrong, _ := tally(details)
+	return float64(correct - wrong/s.wrongsPerRight), float64(len(details))
+}
+
+// tally counts correct, wrong and blank answers
+func tally(details []models.QuestionResult) (correct, wrong, blank int) {
+	for _, d := range details {
+		switch {
+		case d.IsCorrect:
+			correct++
+		case d.IsBlank:
+			blank++
+		default:
+			wrong++
+		}
+	}
+	return correct, wrong, blank
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+
+	"exam-helper/internal/models"
+)
+
+// questionResults builds details with the given counts of correct, wrong and blank answers, all of weight 1
+func questionResults(correct, wrong, blank int) []models.QuestionResult {
+	var details []models.QuestionResult
+	for i := 0; i < correct; i++ {
+		details = append(details, models.QuestionResult{IsCorrect: true, Weight: 1})
+	}
+	for i := 0; i < wrong; i++ {
+		details = append(details, models.QuestionResult{UserAnswer: "A", Weight: 1})
+	}
+	for i := 0; i < blank; i++ {
+		details = append(details, models.QuestionResult{IsBlank: true, Weight: 1})
+	}
+	return details
+}
+
+func TestScorers(t *testing.T) {
+	weighted := []models.QuestionResult{
+		{IsCorrect: true, Weight: 2},
+		{IsCorrect: true, Weight: 0.5},
+		{UserAnswer: "A", Weight: 3},
+		{IsBlank: true, Weight: 1.5},
+	}
+
+	tests := []struct {
+		name    string
+		config  models.ScoringConfig
+		details []models.QuestionResult
+		net     float64
+		max     float64
+	}{
+		{"percentage", models.ScoringConfig{}, questionResults(7, 2, 1), 7, 10},
+		{"negative", models.ScoringConfig{Scheme: models.ScoringNegative, Penalty: 0.25}, questionResults(6, 3, 1), 5.25, 10},
+		{"negative below zero", models.ScoringConfig{Scheme: models.ScoringNegative, Penalty: 1}, questionResults(1, 3, 0), -2, 4},
+		{"cespe", models.ScoringConfig{Scheme: models.ScoringCespe}, questionResults(5, 2, 3), 3, 10},
+		{"wrong cancels right", models.ScoringConfig{Scheme: models.ScoringWrongCancelsRight, WrongsPerRight: 3}, questionResults(6, 3, 1), 5, 10},
+		{"wrong cancels right rounds down", models.ScoringConfig{Scheme: models.ScoringWrongCancelsRight, WrongsPerRight: 3}, questionResults(6, 5, 0), 5, 11},
+		{"wrong cancels right blanks", models.ScoringConfig{Scheme: models.ScoringWrongCancelsRight, WrongsPerRight: 2}, questionResults(4, 1, 5), 4, 10},
+		{"weighted", models.ScoringConfig{Scheme: models.ScoringWeighted}, weighted, 2.5, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := NewScorer(tt.config)
+			if err != nil {
+				t.Fatalf("NewScorer: %v", err)
+			}
+
+			net, max := scorer.Score(tt.details)
+			if net != tt.net || max != tt.max {
+				t.Fatalf("Score = %v/%v, want %v/%v", net, max, tt.net, tt.max)
+			}
+		})
+	}
+}
+
+func TestNewScorerRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ScoringConfig
+	}{
+		{"negative without penalty", models.ScoringConfig{Scheme: models.ScoringNegative}},
+		{"negative with negative penalty", models.ScoringConfig{Scheme: models.ScoringNegative, Penalty: -0.5}},
+		{"weighted with negative weight", models.ScoringConfig{Scheme: models.ScoringWeighted, Weights: map[string]float64{"3": -1}}},
+		{"wrong cancels right without ratio", models.ScoringConfig{Scheme: models.ScoringWrongCancelsRight}},
+		{"wrong cancels right with negative ratio", models.ScoringConfig{Scheme: models.ScoringWrongCancelsRight, WrongsPerRight: -2}},
+		{"unknown scheme", models.ScoringConfig{Scheme: "bonus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if scorer, err := NewScorer(tt.config); err == nil {
+				t.Fatalf("NewScorer accepted the config and returned %T", scorer)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { examAPI } from '../services/api';
+import { QuestionMetadata } from '../types/exam';
 import './AnswerForm.css';
 
 interface AnswerFormProps {
@@ -19,31 +20,26 @@ const AnswerForm: React.FC<AnswerFormProps> = ({
   canSubmit,
   loading
 }) => {
-  const [answerKeyPreview, setAnswerKeyPreview] = useState<Record<string, string>>({});
-  const [totalQuestions, setTotalQuestions] = useState(0);
+  const [questions, setQuestions] = useState<QuestionMetadata[]>([]);
   const [showSubmitConfirm, setShowSubmitConfirm] = useState(false);
+  const totalQuestions = questions.length;
 
   useEffect(() => {
-    const loadAnswerKeyPreview = async () => {
+    const loadExamMetadata = async () => {
       try {
-        const response = await examAPI.getAnswerKeyPreview(examId);
-        setAnswerKeyPreview(response.preview);
-        
-        // Get total questions from preview (this is a simplified approach)
-        // In a real implementation, you might want to parse the full answer key
-        const questionNumbers = Object.keys(response.preview).map(Number).sort((a, b) => a - b);
-        const maxQuestion = Math.max(...questionNumbers);
-        setTotalQuestions(maxQuestion);
+        const response = await examAPI.getExamMetadata(examId);
+        setQuestions(response.metadata.questions);
       } catch (err) {
-        console.error('Failed to load answer key preview:', err);
-        // Fallback: assume 50 questions
-        setTotalQuestions(50);
+        console.error('Failed to load exam metadata:', err);
       }
     };
 
-    loadAnswerKeyPreview();
+    loadExamMetadata();
   }, [examId]);
 
+  // Options are listed per question; the header shows the ones shared by the first question
+  const options = questions.length > 0 ? questions[0].options : [];
+
   const handleAnswerChange = (questionNumber: string, answer: string) => {
     const newAnswers = { ...answers, [questionNumber]: answer };
     onAnswersChange(newAnswers);
@@ -71,8 +67,8 @@ const AnswerForm: React.FC<AnswerFormProps> = ({
   const renderQuestionInputs = () => {
     const inputs = [];
     
-    for (let i = 1; i <= totalQuestions; i++) {
-      const questionNumber = i.toString();
+    for (const question of questions) {
+      const questionNumber = question.number.toString();
       const currentAnswer = answers[questionNumber] || '';
       
       inputs.push(
@@ -81,7 +77,7 @@ const AnswerForm: React.FC<AnswerFormProps> = ({
             {questionNumber}.
           </label>
           <div className="answer-options">
-            {['A', 'B', 'C', 'D', 'E'].map(option => (
+            {question.options.map(option => (
               <label key={option} className="answer-option">
                 <input
                   type="radio"
@@ -119,7 +115,7 @@ const AnswerForm: React.FC<AnswerFormProps> = ({
       </div>
 
       <div className="instructions">
-        <p>📝 Marque a alternativa correta para cada questão (A, B, C, D ou E)</p>
+        <p>📝 Marque a alternativa correta para cada questão ({options.join(', ')})</p>
         <p>💡 Você pode alterar suas respostas a qualquer momento antes de submeter</p>
       </div>
 
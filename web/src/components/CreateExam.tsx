@@ -10,6 +10,7 @@ interface CreateExamProps {
 const CreateExam: React.FC<CreateExamProps> = ({ onExamCreated }) => {
   const [mode, setMode] = useState<ExamMode>('timer');
   const [duration, setDuration] = useState<number>(60);
+  const [options, setOptions] = useState<string>('A-E');
   const [examFile, setExamFile] = useState<File | null>(null);
   const [answerKeyFile, setAnswerKeyFile] = useState<File | null>(null);
   const [loading, setLoading] = useState(false);
@@ -37,6 +38,7 @@ const CreateExam: React.FC<CreateExamProps> = ({ onExamCreated }) => {
       if (mode === 'timer') {
         formData.append('duration', duration.toString());
       }
+      formData.append('options', options);
       formData.append('exam_pdf', examFile);
       formData.append('answer_key', answerKeyFile);
 
@@ -128,6 +130,23 @@ const CreateExam: React.FC<CreateExamProps> = ({ onExamCreated }) => {
             </div>
           )}
 
+          <div className="form-group">
+            <label htmlFor="options" className="form-label">
+              Alternativas:
+            </label>
+            <select
+              id="options"
+              value={options}
+              onChange={(e) => setOptions(e.target.value)}
+              className="form-input"
+            >
+              <option value="A-E">A, B, C, D e E</option>
+              <option value="A-D">A, B, C e D</option>
+              <option value="C/E">Certo/Errado (C/E)</option>
+              <option value="V/F">Verdadeiro/Falso (V/F)</option>
+            </select>
+          </div>
+
           <div className="form-group">
             <label htmlFor="exam-file" className="form-label">
               Arquivo da Prova (PDF):
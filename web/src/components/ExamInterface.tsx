@@ -1,4 +1,5 @@
-import React, { useState, useEffect, useCallback } from 'react';
+import React, { useState, useEffect, useCallback, useRef } from 'react';
+import axios from 'axios';
 import { examAPI } from '../services/api';
 import { Exam, ExamStatus as ExamStatusType, ExamResult } from '../types/exam';
 import Timer from './Timer';
@@ -20,6 +21,10 @@ const ExamInterface: React.FC<ExamInterfaceProps> = ({ examId, onBack }) => {
   const [error, setError] = useState<string>('');
   const [answers, setAnswers] = useState<Record<string, string>>({});
 
+  // Answers the server last acknowledged and their version, so autosave only sends changes
+  const savedAnswers = useRef<Record<string, string>>({});
+  const answersVersion = useRef(0);
+
   // Load exam data
   const loadExam = useCallback(async () => {
     try {
@@ -30,6 +35,9 @@ const ExamInterface: React.FC<ExamInterfaceProps> = ({ examId, onBack }) => {
       
       setExam(examResponse.exam);
       setExamStatus(statusResponse);
+      savedAnswers.current = examResponse.exam.answers || {};
+      answersVersion.current = examResponse.exam.answers_version;
+      setAnswers(examResponse.exam.answers || {});
       setError('');
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Erro ao carregar prova');
@@ -68,6 +76,41 @@ const ExamInterface: React.FC<ExamInterfaceProps> = ({ examId, onBack }) => {
     return () => clearInterval(interval);
   }, [exam, updateStatus]);
 
+  // Autosave changed answers shortly after the user stops editing
+  useEffect(() => {
+    if (!exam || exam.status !== 'active') return;
+
+    const changes: Record<string, string> = {};
+    Object.keys({ ...savedAnswers.current, ...answers }).forEach((question) => {
+      if ((answers[question] || '') !== (savedAnswers.current[question] || '')) {
+        changes[question] = answers[question] || '';
+      }
+    });
+    if (Object.keys(changes).length === 0) return;
+
+    const timeout = setTimeout(async () => {
+      try {
+        const response = await examAPI.saveAnswers(examId, changes, answersVersion.current);
+        savedAnswers.current = response.answers || {};
+        answersVersion.current = response.version;
+      } catch (err) {
+        if (axios.isAxiosError(err) && err.response?.status === 409) {
+          // Another tab saved first; take its version and save these changes on top
+          const latest = await examAPI.getExam(examId).then(response => response.exam, () => null);
+          if (latest && latest.status === 'active') {
+            savedAnswers.current = latest.answers || {};
+            answersVersion.current = latest.answers_version;
+            setAnswers(prev => ({ ...prev }));
+            return;
+          }
+        }
+        console.error('Autosave failed:', err);
+      }
+    }, 1000);
+
+    return () => clearTimeout(timeout);
+  }, [answers, exam, examId]);
+
   const handleStartExam = async () => {
     try {
       setLoading(true);
@@ -95,8 +138,19 @@ const ExamInterface: React.FC<ExamInterfaceProps> = ({ examId, onBack }) => {
     }
   };
 
+  // Show the result the server stored, e.g. after its deadline timer graded the autosaved answers
+  const loadStoredResult = async () => {
+    try {
+      const response = await examAPI.getResult(examId);
+      setResult(response.result);
+      setExam(prev => prev ? { ...prev, status: 'expired' } : null);
+    } catch (err) {
+      console.error('Failed to load result:', err);
+    }
+  };
+
   const handleAutoSubmit = async () => {
-    if (!exam || Object.keys(answers).length === 0) return;
+    if (!exam) return;
 
     try {
       const response = await examAPI.submitAnswers(examId, answers);
@@ -104,6 +158,10 @@ const ExamInterface: React.FC<ExamInterfaceProps> = ({ examId, onBack }) => {
       setExam(prev => prev ? { ...prev, status: 'expired' } : null);
     } catch (err) {
       console.error('Auto-submit failed:', err);
+      // The exam already ended on the server, so it has a result of its own
+      if (axios.isAxiosError(err) && err.response && err.response.status < 500) {
+        await loadStoredResult();
+      }
     }
   };
 
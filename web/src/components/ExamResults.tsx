@@ -52,6 +52,7 @@ const ExamResults: React.FC<ExamResultsProps> = ({ result, examMode, onBack }) =
       score: result.score,
       correct_answers: result.correct_answers,
       wrong_answers: result.wrong_answers,
+      blank_answers: result.blank_answers,
       total_questions: result.total_questions,
       time_taken: formatTime(result.time_taken),
       exam_mode: examMode,
@@ -102,6 +103,11 @@ const ExamResults: React.FC<ExamResultsProps> = ({ result, examMode, onBack }) =
             <div className="stat-value">{result.wrong_answers}</div>
             <div className="stat-label">Erros</div>
           </div>
+
+          <div className="stat-item blank">
+            <div className="stat-value">{result.blank_answers}</div>
+            <div className="stat-label">Em branco</div>
+          </div>
           
           <div className="stat-item total">
             <div className="stat-value">{result.total_questions}</div>
@@ -125,6 +131,10 @@ const ExamResults: React.FC<ExamResultsProps> = ({ result, examMode, onBack }) =
               className="performance-fill wrong-fill"
               style={{ width: `${(result.wrong_answers / result.total_questions) * 100}%` }}
             />
+            <div 
+              className="performance-fill blank-fill"
+              style={{ width: `${(result.blank_answers / result.total_questions) * 100}%` }}
+            />
           </div>
           <div className="bar-legend">
             <span className="legend-item correct">
@@ -135,6 +145,10 @@ const ExamResults: React.FC<ExamResultsProps> = ({ result, examMode, onBack }) =
               <span className="legend-color"></span>
               Incorretas ({result.wrong_answers})
             </span>
+            <span className="legend-item blank">
+              <span className="legend-color"></span>
+              Em branco ({result.blank_answers})
+            </span>
           </div>
         </div>
 
@@ -210,7 +224,7 @@ const ExamResults: React.FC<ExamResultsProps> = ({ result, examMode, onBack }) =
                   </div>
                   
                   <div className="result-icon">
-                    {detail.is_correct ? '✅' : '❌'}
+                    {detail.is_correct ? '✅' : detail.is_blank ? '⬜' : '❌'}
                   </div>
                 </div>
               ))}
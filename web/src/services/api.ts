@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { Exam, CreateExamRequest, SubmitAnswersRequest, ExamResult, ExamStatus } from '../types/exam';
+import { Exam, CreateExamRequest, SubmitAnswersRequest, ExamResult, ExamStatus, ExamMetadata } from '../types/exam';
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || '/api/v1';
 
@@ -8,14 +8,39 @@ const api = axios.create({
   timeout: 30000,
 });
 
+// Owner tokens are returned once when an exam is created and kept in this browser
+const ownerTokenKey = (examId: string) => `exam-owner-token:${examId}`;
+
+export const ownerTokens = {
+  save: (examId: string, token: string) => localStorage.setItem(ownerTokenKey(examId), token),
+  get: (examId: string) => localStorage.getItem(ownerTokenKey(examId)),
+  all: (): string[] =>
+    Object.keys(localStorage)
+      .filter((key) => key.startsWith(ownerTokenKey('')))
+      .map((key) => localStorage.getItem(key) || '')
+      .filter((token) => token !== ''),
+};
+
+const ownerHeaders = (examId: string): Record<string, string> => {
+  const token = ownerTokens.get(examId);
+  return token ? { 'X-Owner-Token': token } : {};
+};
+
 export const examAPI = {
   // Create a new exam
-  createExam: async (formData: FormData): Promise<{ exam: Exam; message: string }> => {
+  createExam: async (formData: FormData): Promise<{ exam: Exam; owner_token: string; message: string }> => {
     const response = await api.post('/exams', formData, {
       headers: {
         'Content-Type': 'multipart/form-data',
       },
     });
+    ownerTokens.save(response.data.exam.id, response.data.owner_token);
+    return response.data;
+  },
+
+  // List the exams created in this browser, newest first; pass next_cursor back as cursor to get the following page
+  listExams: async (params: Record<string, string> = {}): Promise<{ exams: Exam[]; next_cursor: string }> => {
+    const response = await api.get('/exams', { params, headers: { 'X-Owner-Token': ownerTokens.all().join(',') } });
     return response.data;
   },
 
@@ -25,27 +50,57 @@ export const examAPI = {
     return response.data;
   },
 
+  // Archive an exam; only its owner can do this
+  archiveExam: async (examId: string): Promise<{ exam: Exam; message: string }> => {
+    const response = await api.post(`/exams/${examId}/archive`, null, { headers: ownerHeaders(examId) });
+    return response.data;
+  },
+
+  // Delete an exam and its files; only its owner can do this
+  deleteExam: async (examId: string): Promise<{ message: string }> => {
+    const response = await api.delete(`/exams/${examId}`, { headers: ownerHeaders(examId) });
+    return response.data;
+  },
+
   // Start an exam
   startExam: async (examId: string): Promise<{ exam: Exam; message: string }> => {
     const response = await api.post(`/exams/${examId}/start`);
     return response.data;
   },
 
+  // Autosave partial answers; version must be the last version returned by the server
+  saveAnswers: async (examId: string, answers: Record<string, string>, version: number): Promise<{ answers: Record<string, string>; version: number; message: string }> => {
+    const response = await api.patch(`/exams/${examId}/answers`, { answers, version });
+    return response.data;
+  },
+
   // Submit answers
   submitAnswers: async (examId: string, answers: Record<string, string>): Promise<{ result: ExamResult; message: string }> => {
     const response = await api.post(`/exams/${examId}/submit`, { answers });
     return response.data;
   },
 
+  // Get the stored result of a completed or expired exam
+  getResult: async (examId: string): Promise<{ result: ExamResult; history: ExamResult[] }> => {
+    const response = await api.get(`/exams/${examId}/result`);
+    return response.data;
+  },
+
   // Get exam status
   getExamStatus: async (examId: string): Promise<ExamStatus> => {
     const response = await api.get(`/exams/${examId}/status`);
     return response.data;
   },
 
-  // Get answer key preview
-  getAnswerKeyPreview: async (examId: string): Promise<{ preview: Record<string, string> }> => {
-    const response = await api.get(`/exams/${examId}/answer-key-preview`);
+  // Get the questions of an exam and their options, without the answers
+  getExamMetadata: async (examId: string): Promise<{ metadata: ExamMetadata }> => {
+    const response = await api.get(`/exams/${examId}/metadata`);
+    return response.data;
+  },
+
+  // Get answer key preview; only the owner can see it before the exam ends
+  getAnswerKeyPreview: async (examId: string): Promise<{ preview: Record<string, string>; options: string[] }> => {
+    const response = await api.get(`/exams/${examId}/answer-key-preview`, { headers: ownerHeaders(examId) });
     return response.data;
   },
 };
@@ -1,6 +1,6 @@
 export type ExamMode = 'timer' | 'stopwatch';
 
-export type ExamStatus = 'pending' | 'active' | 'completed' | 'expired';
+export type ExamStatus = 'pending' | 'active' | 'completed' | 'expired' | 'archived';
 
 export interface Exam {
   id: string;
@@ -12,10 +12,30 @@ export interface Exam {
   start_time?: string;
   end_time?: string;
   answers: Record<string, string>;
+  answers_version: number;
+  key_revision: number;
+  option_set: OptionSet;
+  scoring: ScoringConfig;
+  result?: ExamResult;
+  owner?: string;
+  archived_at?: string;
   created_at: string;
   updated_at: string;
 }
 
+export interface OptionSet {
+  name: string;
+  options: string[];
+  aliases?: Record<string, string>;
+}
+
+export interface ScoringConfig {
+  scheme: 'percentage' | 'negative' | 'cespe' | 'weighted' | 'wrong_cancels_right';
+  penalty?: number;
+  wrongs_per_right?: number;
+  weights?: Record<string, number>;
+}
+
 export interface CreateExamRequest {
   mode: ExamMode;
   duration?: number; // in minutes
@@ -29,19 +49,69 @@ export interface ExamResult {
   exam_id: string;
   total_questions: number;
   correct_answers: number;
-  wrong_answers: number;
-  score: number;
+  wrong_answers: number; // answered incorrectly
+  blank_answers: number;
+  score: number; // percentage of the maximum score
+  net_score: number;
+  max_score: number;
+  weighted_score: number;
+  weighted_total: number;
+  sections?: SectionResult[];
+  scoring: ScoringConfig;
   time_taken: number; // in milliseconds
   answers: Record<string, string>;
   correct_key: Record<string, string>;
   details: QuestionResult[];
+  submitted_at: string;
+  submission: 'on_time' | 'grace_period' | 'late' | 'late_autosaved' | 'expired';
+  late_by?: number; // in nanoseconds
+  graded_at: string;
+  annulled_questions: number;
+  answer_key_checksum: string;
+  answer_key_revision: number;
 }
 
 export interface QuestionResult {
   question_number: string;
   user_answer: string;
   correct_answer: string;
+  accepted_answers: string[];
   is_correct: boolean;
+  is_blank: boolean;
+  is_annulled?: boolean;
+  weight: number;
+  section?: string;
+}
+
+export interface SectionResult {
+  name: string;
+  total_questions: number;
+  correct_answers: number;
+  wrong_answers: number;
+  blank_answers: number;
+  weighted_score: number;
+  weighted_total: number;
+}
+
+export interface ExamMetadata {
+  total_questions: number;
+  question_numbers: number[];
+  questions: QuestionMetadata[];
+  gaps: QuestionGap[];
+  option_set: string;
+  sections?: string[];
+}
+
+export interface QuestionMetadata {
+  number: number;
+  options: string[];
+  section?: string;
+  weight: number;
+}
+
+export interface QuestionGap {
+  from: number;
+  to: number;
 }
 
 export interface ExamStatus {